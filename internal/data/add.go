@@ -0,0 +1,23 @@
+package data
+
+import (
+	"github.com/spf13/pflag"
+)
+
+var addToFlag string
+
+func init() {
+	pflag.StringVar(&addToFlag, "add-to", "", "Ledger journal or CSV file to append transactions added in the TUI to, defaults to the ledger file or the first CSV file")
+}
+
+// Return the file from --add-to, empty if it is not set
+func AddToFile() string {
+	return addToFlag
+}
+
+// Appender is implemented by data sources that can append new transactions to their files
+type Appender interface {
+	// File new transactions are appended to without --add-to, empty if there is none
+	DefaultAppendFile() string
+	AppendTransaction(t *Transaction, file string) error
+}
@@ -0,0 +1,159 @@
+package data
+
+import (
+	"sort"
+	"time"
+)
+
+// Balance is the amount held by an account at the end of Date, e.g. from a balance assertion or an opening balance
+// Money owed, e.g. on a credit card, is a negative balance
+type Balance struct {
+	Date        time.Time
+	AccountType AccountType
+	Account     string
+	Amount      Money
+	// Currency of Amount, empty means DefaultCurrency
+	Currency string
+}
+
+// BalanceSource is implemented by data sources that also know account balances
+type BalanceSource interface {
+	// Balances found while loading transactions
+	Balances() []Balance
+}
+
+func (a AccountType) IsLiability() bool {
+	return a == AcctCreditCard
+}
+
+// Return how much the transaction changes the balance of the account
+func (t *Transaction) BalanceChange(account string) Money {
+	var change Money
+	switch t.Type {
+	case Income:
+		if t.Account == account {
+			change += t.Amount
+		}
+	case Expense:
+		if t.Account == account {
+			change -= t.Amount
+		}
+	case Transfer:
+		if t.Account == account {
+			change -= t.Amount
+		}
+		if t.ToAccount == account {
+			change += t.Amount
+		}
+	}
+	return change
+}
+
+// Balance of an account at the end of a date
+type balancePoint struct {
+	date    time.Time
+	balance Money
+}
+
+// BalanceHistory tracks the running balance of each account
+type BalanceHistory struct {
+	accountTypes map[string]AccountType
+	points       map[string][]balancePoint
+}
+
+// Build balance history from transactions and known balances
+// A known balance replaces the running balance at the end of its date
+func NewBalanceHistory(transactions []*Transaction, balances []Balance) *BalanceHistory {
+	h := &BalanceHistory{
+		accountTypes: map[string]AccountType{},
+		points:       map[string][]balancePoint{},
+	}
+
+	type change struct {
+		date      time.Time
+		delta     Money
+		assertion bool
+	}
+	changes := map[string][]change{}
+	for _, t := range transactions {
+		h.accountTypes[t.Account] = t.AccountType
+		changes[t.Account] = append(changes[t.Account], change{date: t.Date, delta: t.BalanceChange(t.Account)})
+		if t.IsTransfer() {
+			h.accountTypes[t.ToAccount] = t.ToAccountType
+			changes[t.ToAccount] = append(changes[t.ToAccount], change{date: t.Date, delta: t.BalanceChange(t.ToAccount)})
+		}
+	}
+	for _, b := range balances {
+		if _, exist := h.accountTypes[b.Account]; !exist {
+			h.accountTypes[b.Account] = b.AccountType
+		}
+		changes[b.Account] = append(changes[b.Account], change{date: b.Date, delta: b.Amount, assertion: true})
+	}
+
+	for account, list := range changes {
+		// Known balances apply after all transactions on the same date
+		sort.SliceStable(list, func(i, j int) bool {
+			a, b := list[i], list[j]
+			if !a.date.Equal(b.date) {
+				return a.date.Before(b.date)
+			}
+			return !a.assertion && b.assertion
+		})
+
+		var running Money
+		points := []balancePoint{}
+		for _, c := range list {
+			if c.assertion {
+				running = c.delta
+			} else {
+				running += c.delta
+			}
+			if n := len(points); n > 0 && points[n-1].date.Equal(c.date) {
+				points[n-1].balance = running
+			} else {
+				points = append(points, balancePoint{c.date, running})
+			}
+		}
+		h.points[account] = points
+	}
+	return h
+}
+
+// Return the balance of the account at the start of the date, i.e. after all transactions before the date
+func (h *BalanceHistory) BalanceBefore(account string, date time.Time) Money {
+	points := h.points[account]
+	i := sort.Search(len(points), func(i int) bool {
+		return !points[i].date.Before(date)
+	})
+	if i == 0 {
+		return 0
+	}
+	return points[i-1].balance
+}
+
+// Return balances of all accounts at the start of the date, i.e. at the end of the previous day
+func (h *BalanceHistory) BalancesBefore(date time.Time) []Balance {
+	balances := []Balance{}
+	for account := range h.points {
+		balances = append(balances, Balance{
+			Date:        date.AddDate(0, 0, -1),
+			AccountType: h.accountTypes[account],
+			Account:     account,
+			Amount:      h.BalanceBefore(account, date),
+		})
+	}
+	return balances
+}
+
+// Return total assets and liabilities at the start of the date, liabilities are negative
+func (h *BalanceHistory) NetWorthBefore(date time.Time) (Money, Money) {
+	var assets, liabilities Money
+	for _, b := range h.BalancesBefore(date) {
+		if b.AccountType.IsLiability() {
+			liabilities += b.Amount
+		} else {
+			assets += b.Amount
+		}
+	}
+	return assets, liabilities
+}
@@ -0,0 +1,101 @@
+package beancount
+
+import (
+	"cashd/internal/data"
+	"cashd/internal/data/ledger"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func (b *BeancountDataSource) EditTransaction(t *data.Transaction, edit data.TransactionEdit) error {
+	return ledger.EditEntry(t, edit, beancountSyntax{})
+}
+
+// Beancount entries are patched like journal entries, with their own headers, postings and metadata
+type beancountSyntax struct{}
+
+func (beancountSyntax) JournalPosting(trimmed string) (string, error) {
+	if isBeancountMetadata(trimmed) {
+		return "", nil
+	}
+	_, journalPosting, err := journalPosting(trimmed)
+	return journalPosting, err
+}
+
+func (beancountSyntax) CheckAccountName(name string) error {
+	if strings.ContainsAny(name, " \t;") {
+		return fmt.Errorf("beancount account names can't contain spaces or ';': %s", name)
+	}
+	return nil
+}
+
+// Account names have no spaces, so the amount follows the first one
+func (beancountSyntax) AmountStart(line string) int {
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	if i := strings.IndexAny(line[indent:], " \t"); i >= 0 {
+		return indent + i
+	}
+	return -1
+}
+
+func (beancountSyntax) ReplaceDescription(line, desc string) (string, error) {
+	return replaceBeancountNarration(line, desc)
+}
+
+func (beancountSyntax) AddTags(line string, tags []string) string {
+	return addBeancountTags(line, tags)
+}
+
+// Replace the payee and narration strings of a beancount header, "payee | narration" sets both
+func replaceBeancountNarration(line, desc string) (string, error) {
+	strs := strconv.Quote(desc)
+	if payee, narration, found := strings.Cut(desc, " | "); found {
+		strs = strconv.Quote(payee) + " " + strconv.Quote(narration)
+	}
+
+	// The strings follow the date and the flag
+	fields := beancountDirectiveRegex.FindStringSubmatchIndex(strings.TrimSuffix(line, "\r"))
+	if fields == nil || fields[6] < 0 {
+		return "", fmt.Errorf("invalid transaction header %q", line)
+	}
+	start := fields[6]
+	end := start
+	for rest := line[end:]; strings.HasPrefix(rest, `"`); rest = line[end:] {
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return "", fmt.Errorf("invalid string in transaction header %q", line)
+		}
+		end += len(quoted)
+		// Spaces between the payee and narration
+		if next := strings.TrimLeft(line[end:], " \t"); strings.HasPrefix(next, `"`) {
+			end = len(line) - len(next)
+		}
+	}
+	if start == end {
+		strs += " "
+	}
+	return line[:start] + strs + line[end:], nil
+}
+
+// Add the tags after the strings and tags of a beancount header, before its comment
+func addBeancountTags(line string, tags []string) string {
+	end := len(strings.TrimSuffix(line, "\r"))
+	inString := false
+	for i := 0; i < end; i++ {
+		switch line[i] {
+		case '\\':
+			if inString {
+				i++
+			}
+		case '"':
+			inString = !inString
+		case ';':
+			if !inString {
+				end = i
+			}
+		}
+	}
+	before := strings.TrimRight(line[:end], " \t")
+	return before + " #" + strings.Join(tags, " #") + line[len(before):]
+}
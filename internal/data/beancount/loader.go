@@ -0,0 +1,67 @@
+package beancount
+
+import (
+	"cashd/internal/data"
+
+	"github.com/spf13/pflag"
+)
+
+// BeancountDataSource reads beancount files, which map to transactions like ledger journals
+type BeancountDataSource struct {
+	prices   []data.Price
+	balances []data.Balance
+	files    []string
+}
+
+var beancountFileFlag string
+
+func init() {
+	pflag.StringVar(&beancountFileFlag, "beancount", "", "Beancount file path")
+}
+
+func (b *BeancountDataSource) Name() string {
+	return "beancount"
+}
+
+func (b *BeancountDataSource) LoadTransactions() ([]*data.Transaction, error) {
+	j, err := readBeancount(beancountFileFlag)
+	if err != nil {
+		return nil, err
+	}
+	transactions := j.Transactions()
+	// Commodities are codes like OFX currencies, so USD amounts match the dollars of other data sources
+	for _, t := range transactions {
+		t.Currency = data.CurrencyOfCode(t.Currency)
+	}
+	b.prices = j.Prices()
+	for i := range b.prices {
+		b.prices[i].Commodity = data.CurrencyOfCode(b.prices[i].Commodity)
+		b.prices[i].Currency = data.CurrencyOfCode(b.prices[i].Currency)
+	}
+	b.balances = j.Balances()
+	for i := range b.balances {
+		b.balances[i].Currency = data.CurrencyOfCode(b.balances[i].Currency)
+	}
+	b.files = j.Files()
+	return transactions, nil
+}
+
+func (b *BeancountDataSource) Prices() []data.Price {
+	return b.prices
+}
+
+func (b *BeancountDataSource) Balances() []data.Balance {
+	return b.balances
+}
+
+func (b *BeancountDataSource) WatchedFiles() []string {
+	return b.files
+}
+
+func (b *BeancountDataSource) Preferred() bool {
+	return b.Enabled()
+}
+
+func (b *BeancountDataSource) Enabled() bool {
+	return beancountFileFlag != ""
+}
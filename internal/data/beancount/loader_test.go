@@ -0,0 +1,54 @@
+package beancount
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cashd/internal/data"
+)
+
+func TestLoadTransactions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.beancount")
+	content := `2024-01-01 open Assets:Checking USD
+2024-01-01 open Expenses:Food
+
+2024-01-10 * "Cafe" "Lunch" #work
+  Expenses:Food  12.50 USD
+  Assets:Checking
+
+2024-01-12 * "Hotel"
+  Expenses:Food  20.00 EUR @ 1.10 USD
+  Assets:Checking  -22.00 USD
+
+2024-02-01 balance Assets:Checking  -34.50 USD
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	beancountFileFlag = path
+	defer func() { beancountFileFlag = "" }()
+
+	b := &BeancountDataSource{}
+	transactions, err := b.LoadTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(transactions))
+	}
+	lunch := transactions[0]
+	if lunch.Description != "Cafe | Lunch" || lunch.Account != "Checking" || lunch.Category != "Food" ||
+		lunch.Amount != 1250 || lunch.Currency != data.DefaultCurrency || !lunch.HasTag("work") || lunch.Line != 4 {
+		t.Errorf("unexpected transaction %+v", lunch)
+	}
+	if hotel := transactions[1]; hotel.Amount != 2000 || hotel.Currency != "EUR" {
+		t.Errorf("unexpected transaction %+v", hotel)
+	}
+	if prices := b.Prices(); len(prices) != 1 || prices[0].Commodity != "EUR" || prices[0].Currency != data.DefaultCurrency {
+		t.Errorf("unexpected prices %+v", prices)
+	}
+	if balances := b.Balances(); len(balances) != 1 || balances[0].Amount != -3450 || balances[0].Currency != data.DefaultCurrency {
+		t.Errorf("unexpected balances %+v", balances)
+	}
+}
@@ -0,0 +1,378 @@
+package beancount
+
+import (
+	"cashd/internal/data"
+	"cashd/internal/data/ledger"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Beancount account roots and the journal account types they map to
+var beancountRoots = map[string]string{
+	"Assets":      "assets",
+	"Liabilities": "liability",
+	"Income":      "income",
+	"Expenses":    "expenses",
+	"Equity":      "equity",
+}
+
+var (
+	// Dated directive: date, then a transaction flag or a directive keyword, then the rest
+	beancountDirectiveRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\s+(\S+)(?:\s+(.*))?$`)
+	// Undated include directive, e.g. include "2024.beancount"
+	beancountIncludeRegex = regexp.MustCompile(`^include\s+"([^"]+)"`)
+	// Posting: optional flag, account, then the amount with optional cost and price
+	beancountPostingRegex = regexp.MustCompile(`^(?:[*!]\s+)?([A-Z][^\s]*)(?:\s+(.*))?$`)
+	// Cost in braces, e.g. {12.00 USD}, {{120 USD}} or {12.00 USD, 2024-01-01, "lot"}
+	beancountCostRegex = regexp.MustCompile(`\{\{?([^}]*)\}?\}`)
+)
+
+// beancountParser reads beancount files into the same entries as journal files,
+// so postings map to transactions with the same rules
+type beancountParser struct {
+	journal ledger.Journal
+	// Current file and line
+	file string
+	line int
+	// Date of the current entry
+	date time.Time
+
+	// Dates accounts are opened and closed, and the first and last dates they are posted to
+	opened map[string]time.Time
+	closed map[string]time.Time
+	posted map[string][2]time.Time
+}
+
+func newBeancountParser() *beancountParser {
+	return &beancountParser{
+		opened: map[string]time.Time{},
+		closed: map[string]time.Time{},
+		posted: map[string][2]time.Time{},
+	}
+}
+
+// readBeancount reads a beancount file and all files it includes
+func readBeancount(path string) (*ledger.Journal, error) {
+	p := newBeancountParser()
+	if err := p.parseFile(path, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	p.journal.EndEntry()
+	p.checkOpenClose()
+	return &p.journal, nil
+}
+
+// Log postings outside the dates their accounts are open, which beancount itself rejects
+// Directives can come in any order, so this is only checked once all files are read
+func (p *beancountParser) checkOpenClose() {
+	for account, dates := range p.posted {
+		if opened, ok := p.opened[account]; !ok || dates[0].Before(opened) {
+			log.Printf("posting to %s on %s before it is opened\n", account, dates[0].Format(time.DateOnly))
+		}
+		if closed, ok := p.closed[account]; ok && dates[1].After(closed) {
+			log.Printf("posting to %s on %s after it is closed\n", account, dates[1].Format(time.DateOnly))
+		}
+	}
+}
+
+// Parse the beancount file and all files it includes
+// including holds the files currently being parsed to detect include cycles
+func (p *beancountParser) parseFile(path string, including map[string]bool) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve beancount path %s: %w", path, err)
+	}
+	if including[absPath] {
+		return fmt.Errorf("beancount file %s includes itself", path)
+	}
+	including[absPath] = true
+	defer delete(including, absPath)
+
+	p.journal.AddFile(absPath)
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return fmt.Errorf("failed to read beancount file %s: %w", path, err)
+	}
+
+	for lineNum, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if matches := beancountIncludeRegex.FindStringSubmatch(line); matches != nil {
+			p.journal.EndEntry()
+			pattern := matches[1]
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(filepath.Dir(absPath), pattern)
+			}
+			p.journal.AddFile(pattern)
+			files, err := filepath.Glob(pattern)
+			if err == nil && len(files) == 0 {
+				err = fmt.Errorf("included file not found: %s", pattern)
+			}
+			for _, f := range files {
+				if err != nil {
+					break
+				}
+				err = p.parseFile(f, including)
+			}
+			if err != nil {
+				return fmt.Errorf("%s:%d: %w", path, lineNum+1, err)
+			}
+			continue
+		}
+		p.file, p.line = absPath, lineNum+1
+		if err := p.parseLine(line); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNum+1, err)
+		}
+	}
+	// An entry never continues into the next file
+	p.journal.EndEntry()
+	return nil
+}
+
+func (p *beancountParser) parseLine(line string) error {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		// An empty line ends the current entry
+		p.journal.EndEntry()
+		return nil
+	}
+
+	if line[0] == ' ' || line[0] == '\t' {
+		if p.journal.InEntry() && isBeancountMetadata(trimmed) {
+			// Metadata of the transaction and its postings are tags with a value
+			key, value, _ := strings.Cut(stripComment(trimmed), ":")
+			value = strings.TrimSpace(value)
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			p.journal.AddTag(key, value)
+			return nil
+		} else if !p.journal.InEntry() || strings.HasPrefix(trimmed, ";") {
+			// Skip comments and lines that belong to other directives
+			return nil
+		}
+		pst, err := p.parsePosting(trimmed)
+		if err != nil {
+			return err
+		}
+		return p.journal.AddPosting(pst)
+	}
+
+	// Any unindented line ends the current entry
+	p.journal.EndEntry()
+
+	// Comments are stripped by each directive, as transaction headers can have ';' in strings
+	matches := beancountDirectiveRegex.FindStringSubmatch(line)
+	if matches == nil {
+		// Comments, options, plugins, pushtag and poptag
+		return nil
+	}
+	date, err := time.ParseInLocation(time.DateOnly, matches[1], time.Local)
+	if err != nil {
+		return err
+	}
+	keyword, rest := matches[2], strings.TrimSpace(matches[3])
+	if keyword != "*" && keyword != "!" && keyword != "txn" {
+		rest = stripComment(rest)
+	}
+
+	switch keyword {
+	case "*", "!", "txn":
+		desc, tags, err := parseBeancountNarration(rest)
+		if err != nil {
+			return err
+		}
+		p.journal.StartEntry(date, desc, p.file, p.line)
+		p.date = date
+		for _, tag := range tags {
+			p.journal.AddTag(tag, "")
+		}
+	case "open", "close":
+		account := strings.Fields(rest)
+		if len(account) == 0 {
+			return fmt.Errorf("%s directive without an account", keyword)
+		}
+		if keyword == "open" {
+			p.opened[account[0]] = date
+		} else {
+			p.closed[account[0]] = date
+		}
+	case "balance":
+		// Balances are checked at the start of the date, i.e. the end of the previous day
+		fields := strings.Fields(rest)
+		if i := slices.Index(fields, "~"); i >= 0 {
+			// Tolerance, e.g. 100.00 ~ 0.01 USD
+			fields = slices.Delete(fields, i, min(i+2, len(fields)))
+		}
+		if len(fields) < 2 {
+			return fmt.Errorf("invalid balance directive")
+		}
+		typeStr, account, err := beancountAccount(fields[0])
+		if err != nil {
+			return err
+		}
+		amount, commodity, err := data.ParseAmount(strings.Join(fields[1:], " "))
+		if err != nil {
+			return err
+		}
+		p.journal.AddBalance(data.Balance{
+			Date:        date.AddDate(0, 0, -1),
+			AccountType: ledger.AccountType(typeStr, account),
+			Account:     account,
+			Amount:      amount,
+			Currency:    commodity,
+		})
+	case "price":
+		price, err := data.ParsePriceDirective(fmt.Sprintf("P %s %s", matches[1], rest))
+		if err != nil {
+			return err
+		}
+		p.journal.AddPrice(price)
+	default:
+		// pad, note, document, event, commodity, query and custom directives don't affect transactions
+		// pad is covered by the balance directive that follows it
+	}
+	return nil
+}
+
+// Parse a posting, e.g. Assets:Checking  -10.00 USD, Assets:Stock 10 AAPL {150.00 USD} or Expenses:Food
+// Return the posting in journal syntax, and record the date it posts to its account
+func (p *beancountParser) parsePosting(line string) (string, error) {
+	fullAccount, journalPosting, err := journalPosting(line)
+	if err != nil {
+		return "", err
+	}
+	if dates, ok := p.posted[fullAccount]; !ok {
+		p.posted[fullAccount] = [2]time.Time{p.date, p.date}
+	} else {
+		p.posted[fullAccount] = [2]time.Time{minDate(dates[0], p.date), maxDate(dates[1], p.date)}
+	}
+	return journalPosting, nil
+}
+
+// Return the account of a posting and the posting in journal syntax
+// The account is split into its type and name as in journal postings, e.g. Assets:Checking is assets:Checking
+func journalPosting(line string) (string, string, error) {
+	matches := beancountPostingRegex.FindStringSubmatch(stripComment(line))
+	if matches == nil {
+		return "", "", fmt.Errorf("invalid posting %q", line)
+	}
+	fullAccount, amountStr := matches[1], strings.TrimSpace(matches[2])
+	typeStr, account, err := beancountAccount(fullAccount)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Costs in braces take precedence over prices for the weight of the posting, as in beancount
+	if cost := beancountCostRegex.FindStringSubmatch(amountStr); cost != nil {
+		costAmount, _, _ := strings.Cut(cost[1], ",")
+		amountStr = strings.TrimSpace(strings.Replace(amountStr, cost[0], "", 1))
+		amountStr, _, _ = strings.Cut(amountStr, "@")
+		if costAmount = strings.TrimSpace(costAmount); costAmount != "" {
+			if strings.HasPrefix(cost[0], "{{") || strings.Contains(costAmount, "#") {
+				// Total cost, the per unit part of a compound cost is ignored
+				_, total, _ := strings.Cut(costAmount, "#")
+				if total == "" {
+					total = costAmount
+				}
+				amountStr += " @@ " + strings.TrimSpace(total)
+			} else {
+				amountStr += " @ " + costAmount
+			}
+		}
+	}
+
+	// The amount follows at least 2 spaces in journal postings
+	return fullAccount, fmt.Sprintf("%s:%s  %s", typeStr, account, amountStr), nil
+}
+
+func minDate(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxDate(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// Split a beancount account into the journal account type and the account name, e.g. Assets:US:Checking
+func beancountAccount(fullAccount string) (string, string, error) {
+	root, account, _ := strings.Cut(fullAccount, ":")
+	typeStr, ok := beancountRoots[root]
+	if !ok {
+		return "", "", fmt.Errorf("unknown account root %s in %s", root, fullAccount)
+	}
+	return typeStr, account, nil
+}
+
+// Return the description from the payee and narration strings of a transaction header, and its tags (#tag)
+// Links (^link) are accepted but not kept
+func parseBeancountNarration(s string) (string, []string, error) {
+	strs := []string{}
+	tags := []string{}
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		switch s[0] {
+		case '"':
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid string in transaction header: %s", s)
+			}
+			str, err := strconv.Unquote(quoted)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid string in transaction header: %s", s)
+			}
+			strs = append(strs, str)
+			s = s[len(quoted):]
+		case '#', '^':
+			var token string
+			token, s, _ = strings.Cut(s, " ")
+			if token[0] == '#' && len(token) > 1 {
+				tags = append(tags, token[1:])
+			}
+		case ';':
+			s = ""
+		default:
+			return "", nil, fmt.Errorf("unexpected text in transaction header: %s", s)
+		}
+	}
+
+	switch len(strs) {
+	case 0:
+		return "", tags, nil
+	case 1:
+		return strs[0], tags, nil
+	default:
+		// Payee and narration
+		if strs[0] == "" {
+			return strs[1], tags, nil
+		} else if strs[1] == "" {
+			return strs[0], tags, nil
+		}
+		return strs[0] + " | " + strs[1], tags, nil
+	}
+}
+
+// Metadata lines are key: value pairs with lowercase keys
+func isBeancountMetadata(line string) bool {
+	key, _, found := strings.Cut(line, ":")
+	return found && key != "" && key[0] >= 'a' && key[0] <= 'z' && !strings.ContainsAny(key, " \t")
+}
+
+// Remove a trailing "; comment" from a line
+func stripComment(line string) string {
+	if i := strings.Index(line, ";"); i >= 0 {
+		return strings.TrimRightFunc(line[:i], func(r rune) bool { return r == ' ' || r == '\t' })
+	}
+	return line
+}
@@ -0,0 +1,93 @@
+package data
+
+import (
+	"cashd/internal/date"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Budget of a category for each period
+type Budget struct {
+	Category string         `json:"category"`
+	Amount   Money          `json:"amount"`
+	Period   date.Increment `json:"period"`
+}
+
+const budgetFileName = "budgets.json"
+
+var budgetFileFlag string
+
+func init() {
+	pflag.StringVar(&budgetFileFlag, "budgets", "", fmt.Sprintf("Budget file path, defaults to ~/.config/cashd/%s", budgetFileName))
+}
+
+// Average number of days in a month
+const daysPerMonth = 365.25 / 12
+
+// Return the budget for a duration in months
+func (b Budget) Scaled(months float64) Money {
+	return b.Amount.MulRate(months / b.Period.Months())
+}
+
+// Return the budget for a date range of the increment, all time ranges are prorated by days
+func (b Budget) InDateRange(inc date.Increment, start, end time.Time) Money {
+	if inc != date.AllTime {
+		return b.Scaled(inc.Months())
+	}
+	return b.Scaled(end.Sub(start).Hours() / 24 / daysPerMonth)
+}
+
+// Add the budgets of the descendants to each parent category without its own budget, e.g. Food is the sum of Food:Groceries and Food:Restaurants
+func RollUpBudgets(budgets map[string]Money) map[string]Money {
+	result := maps.Clone(budgets)
+	for category, amount := range budgets {
+		// A parent with its own budget already covers its descendants
+		for parent := ParentCategory(category); parent != ""; parent = ParentCategory(parent) {
+			if _, exist := budgets[parent]; exist {
+				break
+			}
+			result[parent] += amount
+		}
+	}
+	return result
+}
+
+// Load budgets by category from the file specified by --budgets, or the default budget file if it exists
+func LoadBudgets() (map[string]Budget, error) {
+	path := budgetFileFlag
+	if path == "" {
+		path = filepath.Join(configDir, budgetFileName)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && budgetFileFlag == "" {
+			return map[string]Budget{}, nil
+		}
+		return nil, fmt.Errorf("failed to read budget file: %w", err)
+	}
+
+	var budgets []Budget
+	if err := json.Unmarshal(content, &budgets); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal budgets from %s: %w", path, err)
+	}
+	result := map[string]Budget{}
+	for _, b := range budgets {
+		if b.Period == "" {
+			b.Period = date.Monthly
+		} else if b.Period, err = date.ParseIncrement(string(b.Period)); err != nil || b.Period == date.AllTime {
+			return nil, fmt.Errorf("invalid period for the budget of %s in %s, expecting weekly, monthly, quarterly or yearly", b.Category, path)
+		}
+		if b.Category == "" || b.Amount <= 0 {
+			return nil, fmt.Errorf("invalid budget %+v in %s, category and a positive amount are required", b, path)
+		}
+		result[b.Category] = b
+	}
+	return result, nil
+}
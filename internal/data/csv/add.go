@@ -0,0 +1,153 @@
+package csv
+
+import (
+	"bytes"
+	"cashd/internal/data"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// The first CSV file from --csv
+func (s *CsvDataSource) DefaultAppendFile() string {
+	for _, pattern := range csvFiles {
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			return matches[0]
+		}
+	}
+	return ""
+}
+
+// Append the transaction as a row laid out like the header of the file, columns without a field are left empty
+func (s *CsvDataSource) AppendTransaction(t *data.Transaction, file string) error {
+	config, err := getConfig()
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to add to %s: %w", file, err)
+	}
+	header, err := csv.NewReader(bytes.NewReader(content)).Read()
+	if err != nil {
+		return fmt.Errorf("failed to read CSV header of %s: %w", file, err)
+	}
+	columns, err := locateColumns(header, config)
+	if err != nil {
+		return fmt.Errorf("failed to add to %s: %w", file, err)
+	}
+	rec, err := newRecord(t, columns, config)
+	if err != nil {
+		return fmt.Errorf("failed to add to %s: %w", file, err)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.UseCRLF = bytes.Contains(content, []byte("\r\n"))
+	if err := w.Write(rec); err != nil {
+		return fmt.Errorf("failed to add to %s: %w", file, err)
+	}
+	w.Flush()
+	row := buf.String()
+	if !bytes.HasSuffix(content, []byte("\n")) {
+		row = "\n" + row
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to add to %s: %w", file, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(row); err != nil {
+		return fmt.Errorf("failed to add to %s: %w", file, err)
+	}
+	return nil
+}
+
+// Build the record of the transaction, the reverse of parseCsvRecord
+func newRecord(t *data.Transaction, columns *csvColumns, config *config) ([]string, error) {
+	rec := make([]string, len(columns.header))
+	for field, index := range columns.fields {
+		for len(rec) <= index {
+			rec = append(rec, "")
+		}
+		switch field {
+		case "Date":
+			rec[index] = t.Date.Format(config.DateFormats[0])
+		case "Type":
+			key, ok := mappingKey(config.TxnTypeMappings, t.Type)
+			if !ok {
+				return nil, fmt.Errorf("no transaction type in the CSV config maps to %s", t.Type)
+			}
+			rec[index] = key
+		case "Account":
+			rec[index] = t.Account
+		case "AccountType":
+			// Left empty to infer the account type from the name
+			rec[index], _ = mappingKey(config.AccountTypeMappings, t.AccountType)
+		case "ToAccount":
+			rec[index] = t.ToAccount
+		case "ToAccountType":
+			if t.IsTransfer() {
+				rec[index], _ = mappingKey(config.AccountTypeMappings, t.ToAccountType)
+			}
+		case "Category":
+			rec[index] = t.Category
+		case "Description":
+			rec[index] = t.Description
+		case "Currency":
+			rec[index] = t.Currency
+		}
+	}
+	if t.IsTransfer() {
+		if _, ok := columns.fields["ToAccount"]; !ok {
+			return nil, fmt.Errorf("the CSV file has no ToAccount column for transfers")
+		}
+	}
+	if _, ok := columns.fields["Type"]; !ok && t.IsTransfer() {
+		return nil, fmt.Errorf("the CSV file has no Type column for transfers")
+	}
+
+	amount := t.Amount.String()
+	if _, ok := columns.fields["Currency"]; !ok && t.Currency != config.DefaultCurrency {
+		amount = data.FormatAmount(t.Amount, t.Currency)
+	}
+	if config.hasDebitCredit() {
+		// Income is credited, expenses and transfers are debited
+		if t.Type == data.Income {
+			rec[columns.credit] = amount
+		} else {
+			rec[columns.debit] = amount
+		}
+		return rec, nil
+	}
+	index := columns.fields["Amount"]
+	rec[index] = amount
+	if config.SignedAmounts && (t.Type == data.Income) == slices.Contains(config.InvertedSignAccounts, t.Account) {
+		// Money leaving the account is negative, unless the account inverts signs
+		rec[index] = invertSign(amount)
+	}
+	return rec, nil
+}
+
+// Return the value that maps to v in the config, e.g. "expense" for Expense, preferring the lowercase name
+func mappingKey[V ~string](mappings map[string]V, v V) (string, bool) {
+	if mappings[strings.ToLower(string(v))] == v {
+		return strings.ToLower(string(v)), true
+	}
+	keys := []string{}
+	for key, value := range mappings {
+		if value == v {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return "", false
+	}
+	sort.Strings(keys)
+	return keys[0], true
+}
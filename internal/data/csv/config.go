@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"regexp"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -24,22 +25,73 @@ type config struct {
 	TxnTypeMappings     map[string]data.TransactionType  `json:"transaction_types"`
 	AccountTypeMappings map[string]data.AccountType      `json:"account_types"`
 	AccountTypeFromName map[string]data.AccountType      `json:"account_type_from_name"`
+	DefaultCurrency     string                           `json:"default_currency"`
+	// Balance of each account before its first transaction
+	OpeningBalances map[string]data.Money `json:"opening_balances"`
+	// Amounts are signed, positive amounts are income and negative amounts are expenses
+	SignedAmounts bool `json:"signed_amounts"`
+	// Accounts whose signed amounts are inverted, e.g. credit card exports with charges as positive amounts
+	InvertedSignAccounts []string `json:"inverted_sign_accounts"`
+	// Headers of the columns with expenses and income, in place of a single amount column
+	DebitColumn  string `json:"debit_column"`
+	CreditColumn string `json:"credit_column"`
 }
 
-func getConfig() *config {
+// Whether amounts come from separate debit and credit columns
+func (c *config) hasDebitCredit() bool {
+	return c.DebitColumn != "" || c.CreditColumn != ""
+}
+
+// Whether a CSV file must have a column for the field
+// Transaction types can be derived from signed amounts or debit and credit columns instead of a Type column,
+// and transactions without a category are Uncategorized, e.g. for rules to categorize
+func (c *config) fieldRequired(f data.TransactionField) bool {
+	switch {
+	case f.Optional(), f == "Category":
+		return false
+	case f == "Type":
+		return !c.SignedAmounts && !c.hasDebitCredit()
+	case f == "Amount":
+		return !c.hasDebitCredit()
+	default:
+		return true
+	}
+}
+
+// Check options that depend on each other
+func (c *config) validate() error {
+	for pattern := range c.AccountTypeFromName {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid account name pattern: %w", err)
+		}
+	}
+	if c.hasDebitCredit() && (c.DebitColumn == "" || c.CreditColumn == "") {
+		return fmt.Errorf("debit_column and credit_column must be set together")
+	}
+	if c.hasDebitCredit() && c.SignedAmounts {
+		return fmt.Errorf("signed_amounts can't be used with debit_column and credit_column")
+	}
+	if len(c.InvertedSignAccounts) > 0 && !c.SignedAmounts {
+		return fmt.Errorf("inverted_sign_accounts requires signed_amounts")
+	}
+	return nil
+}
+
+// The config is shared by all CSV files and must not be modified
+func getConfig() (*config, error) {
 	if csvConfigFlag == "" {
-		return defaultConfig
+		return defaultConfig, nil
 	}
 
 	fileContent, err := os.ReadFile(csvConfigFlag)
 	if err != nil {
-		panic(fmt.Sprintf("Error reading CSV config file: %v", err))
+		return nil, fmt.Errorf("failed to read CSV config file: %w", err)
 	}
 
 	var c config
 	err = json.Unmarshal(fileContent, &c)
 	if err != nil {
-		panic(fmt.Sprintf("Error unmarshaling CSV config: %v", err))
+		return nil, fmt.Errorf("failed to unmarshal CSV config %s: %w", csvConfigFlag, err)
 	}
 
 	cv := reflect.ValueOf(&c).Elem()
@@ -52,7 +104,11 @@ func getConfig() *config {
 		}
 	}
 
-	return &c
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid CSV config %s: %w", csvConfigFlag, err)
+	}
+
+	return &c, nil
 }
 
 var defaultConfig = func() *config {
@@ -63,11 +119,12 @@ var defaultConfig = func() *config {
 			time.DateTime,
 		},
 		TxnTypeMappings: map[string]data.TransactionType{
-			"income":  data.Income,
-			"inc.":    data.Income,
-			"expense": data.Expense,
-			"exp.":    data.Expense,
-			"exps.":   data.Expense,
+			"income":   data.Income,
+			"inc.":     data.Income,
+			"expense":  data.Expense,
+			"exp.":     data.Expense,
+			"exps.":    data.Expense,
+			"transfer": data.Transfer,
 		},
 		AccountTypeMappings: map[string]data.AccountType{},
 		AccountTypeFromName: map[string]data.AccountType{
@@ -76,6 +133,9 @@ var defaultConfig = func() *config {
 			"saving(s)?$":        data.AcctBankAccount,
 			"credit(\\s?card)?$": data.AcctCreditCard,
 		},
+		DefaultCurrency:      data.DefaultCurrency,
+		OpeningBalances:      map[string]data.Money{},
+		InvertedSignAccounts: []string{},
 	}
 
 	c.Columns = map[string]data.TransactionField{}
@@ -0,0 +1,185 @@
+package csv
+
+import (
+	"bytes"
+	"cashd/internal/data"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Rewrite the row of the transaction in its CSV file, other rows are left as they are
+func (s *CsvDataSource) EditTransaction(t *data.Transaction, edit data.TransactionEdit) error {
+	if t.File == "" || t.Line == 0 {
+		return fmt.Errorf("the file of the transaction is unknown")
+	}
+	config, err := getConfig()
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(t.File)
+	if err != nil {
+		return fmt.Errorf("failed to edit %s: %w", t.File, err)
+	}
+	content, err := os.ReadFile(t.File)
+	if err != nil {
+		return fmt.Errorf("failed to edit %s: %w", t.File, err)
+	}
+
+	rec, lastLine, columns, err := findRecord(content, t.Line, config)
+	if err != nil {
+		return fmt.Errorf("failed to edit %s: %w", t.File, err)
+	}
+	if orig, parseErr := parseCsvRecord(rec, columns, config); parseErr != nil || orig.Date.Format(time.DateOnly) != t.Date.Format(time.DateOnly) {
+		return fmt.Errorf("the row is no longer at %s:%d, reload and try again", t.File, t.Line)
+	}
+
+	set := func(field data.TransactionField, value string) error {
+		index, ok := columns.fields[field]
+		if !ok {
+			return fmt.Errorf("%s has no %s column", t.File, field)
+		}
+		for len(rec) <= index {
+			rec = append(rec, "")
+		}
+		rec[index] = value
+		return nil
+	}
+	if edit.Date.Format(time.DateOnly) != t.Date.Format(time.DateOnly) {
+		// Keep the date format of the row
+		index := columns.fields["Date"]
+		format := config.DateFormats[0]
+		for _, f := range config.DateFormats {
+			if _, err := time.Parse(f, rec[index]); err == nil {
+				format = f
+				break
+			}
+		}
+		if err := set("Date", edit.Date.Format(format)); err != nil {
+			return err
+		}
+	}
+	if edit.Account != t.Account {
+		if err := set("Account", edit.Account); err != nil {
+			return err
+		}
+	}
+	if edit.Category != t.Category {
+		if err := set("Category", edit.Category); err != nil {
+			return err
+		}
+	}
+	if edit.Description != t.Description {
+		if err := set("Description", edit.Description); err != nil {
+			return err
+		}
+	}
+	if added := edit.AddedTags(t); len(added) > 0 {
+		value := strings.Join(added, "; ")
+		if index, ok := columns.fields["Tags"]; ok && index < len(rec) && strings.TrimSpace(rec[index]) != "" {
+			value = rec[index] + "; " + value
+		}
+		if err := set("Tags", value); err != nil {
+			return err
+		}
+	}
+	if index := amountColumn(rec, columns, config); index >= 0 {
+		value := rec[index]
+		if edit.Amount != t.EditableFields().Amount {
+			value = data.ReplaceAmountNumber(value, edit.Amount)
+		}
+		if config.SignedAmounts && slices.Contains(config.InvertedSignAccounts, t.Account) != slices.Contains(config.InvertedSignAccounts, edit.Account) {
+			// The sign of the amount follows the account
+			value = invertSign(value)
+		}
+		rec[index] = value
+	}
+
+	// Encode the row like the rest of the file
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.UseCRLF = bytes.Contains(content, []byte("\r\n"))
+	if err := w.Write(rec); err != nil {
+		return fmt.Errorf("failed to edit %s: %w", t.File, err)
+	}
+	w.Flush()
+	lines := strings.Split(string(content), "\n")
+	row := strings.TrimSuffix(strings.TrimSuffix(buf.String(), "\n"), "\r")
+	if strings.HasSuffix(lines[lastLine-1], "\r") {
+		row += "\r"
+	}
+	lines = slices.Replace(lines, t.Line-1, lastLine, row)
+
+	if err := os.WriteFile(t.File, []byte(strings.Join(lines, "\n")), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to edit %s: %w", t.File, err)
+	}
+	return nil
+}
+
+// Return the record starting at the line, the line it ends at, and the columns of the file
+func findRecord(content []byte, line int, config *config) ([]string, int, *csvColumns, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	header, err := reader.Read()
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns, err := locateColumns(header, config)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if _, ok := err.(*csv.ParseError); ok {
+			// Malformed rows are skipped when loading too
+			continue
+		} else if err != nil {
+			return nil, 0, nil, err
+		}
+		if start, _ := reader.FieldPos(0); start == line {
+			// A quoted field can span lines, the record ends at the line break after it
+			end := reader.InputOffset()
+			lastLine := bytes.Count(content[:end], []byte("\n"))
+			if !bytes.HasSuffix(content[:end], []byte("\n")) {
+				// The last line of the file has no line break
+				lastLine++
+			}
+			return rec, lastLine, columns, nil
+		} else if start > line {
+			break
+		}
+	}
+	return nil, 0, nil, fmt.Errorf("no row at line %d, reload and try again", line)
+}
+
+// Return the index of the column with the amount of the record, -1 if there is none
+func amountColumn(rec []string, columns *csvColumns, config *config) int {
+	if !config.hasDebitCredit() {
+		if index, ok := columns.fields["Amount"]; ok && index < len(rec) {
+			return index
+		}
+		return -1
+	}
+	for _, index := range []int{columns.debit, columns.credit} {
+		if index >= len(rec) {
+			continue
+		}
+		if amount, _, err := data.ParseAmount(rec[index]); err == nil && amount != 0 {
+			return index
+		}
+	}
+	return -1
+}
+
+// Negate a signed amount, e.g. $-12.00 becomes $12.00 and 12.00 becomes -12.00
+func invertSign(value string) string {
+	if i := strings.Index(value, "-"); i >= 0 {
+		return value[:i] + value[i+1:]
+	}
+	return "-" + strings.TrimSpace(value)
+}
@@ -0,0 +1,74 @@
+package csv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cashd/internal/data"
+)
+
+func TestEditTransaction(t *testing.T) {
+	header := "Date,Type,Account,Category,Amount,Description\n"
+	tests := []struct {
+		name    string
+		content string
+		line    int
+		want    string
+	}{
+		{
+			name:    "one line",
+			content: header + "2024-01-10,Expense,Checking,Food,12.50,Lunch\n2024-01-11,Expense,Checking,Food,3.00,Coffee\n",
+			line:    2,
+			want:    header + "2024-01-10,Expense,Checking,Dining,12.50,Lunch\n2024-01-11,Expense,Checking,Food,3.00,Coffee\n",
+		},
+		{
+			name:    "multi-line last field",
+			content: header + "2024-01-10,Expense,Checking,Food,12.50,\"Lunch\nwith\nclients\"\n2024-01-11,Expense,Checking,Food,3.00,Coffee\n",
+			line:    2,
+			want:    header + "2024-01-10,Expense,Checking,Dining,12.50,\"Lunch\nwith\nclients\"\n2024-01-11,Expense,Checking,Food,3.00,Coffee\n",
+		},
+		{
+			name:    "last line without line break",
+			content: header + "2024-01-11,Expense,Checking,Food,3.00,Coffee\n2024-01-10,Expense,Checking,Food,12.50,\"Lunch\nwith clients\"",
+			line:    3,
+			want:    header + "2024-01-11,Expense,Checking,Food,3.00,Coffee\n2024-01-10,Expense,Checking,Dining,12.50,\"Lunch\nwith clients\"",
+		},
+		{
+			name:    "CRLF",
+			content: "Date,Type,Account,Category,Amount,Description\r\n2024-01-10,Expense,Checking,Food,12.50,\"Lunch\r\nwith clients\"\r\n",
+			line:    2,
+			want:    "Date,Type,Account,Category,Amount,Description\r\n2024-01-10,Expense,Checking,Dining,12.50,\"Lunch\r\nwith clients\"\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.csv")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			txn := &data.Transaction{
+				Date:     time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+				Type:     data.Expense,
+				Account:  "Checking",
+				Category: "Food",
+				Amount:   1250,
+				File:     path,
+				Line:     tt.line,
+			}
+			edit := txn.EditableFields()
+			edit.Category = "Dining"
+			if err := (&CsvDataSource{}).EditTransaction(txn, edit); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"cashd/internal/data"
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -11,7 +12,10 @@ import (
 	"github.com/spf13/pflag"
 )
 
-type CsvDataSource struct{}
+type CsvDataSource struct {
+	balances []data.Balance
+	problems []*data.ParseError
+}
 
 var csvFiles []string
 
@@ -19,9 +23,20 @@ func init() {
 	pflag.StringSliceVar(&csvFiles, "csv", []string{}, "CSV file path (can be specified multiple times)")
 }
 
-func (s CsvDataSource) LoadTransactions() ([]*data.Transaction, error) {
+// Transactions and skipped records read from one CSV file
+type csvResult struct {
+	txns     []*data.Transaction
+	problems []*data.ParseError
+	err      error
+}
+
+func (s *CsvDataSource) Name() string {
+	return "csv"
+}
+
+func (s *CsvDataSource) LoadTransactions() ([]*data.Transaction, error) {
 	allTxns := []*data.Transaction{}
-	txnChan := make(chan []*data.Transaction)
+	s.problems = []*data.ParseError{}
 
 	resolvedFilePaths := []string{}
 	for _, pattern := range csvFiles {
@@ -35,80 +50,148 @@ func (s CsvDataSource) LoadTransactions() ([]*data.Transaction, error) {
 		return []*data.Transaction{}, nil
 	}
 
-	errChan := make(chan error, len(resolvedFilePaths))
+	config, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+	policy, err := data.GetParseErrorPolicy()
+	if err != nil {
+		return nil, err
+	}
 
+	// Buffered so that no reader is left blocked when returning early on error
+	resultChan := make(chan csvResult, len(resolvedFilePaths))
 	for _, filePath := range resolvedFilePaths {
 		go func(fp string) {
-			txns, err := readCsv(fp)
-			if err != nil {
-				errChan <- err
-			} else {
-				txnChan <- txns
-			}
+			txns, problems, err := readCsv(fp, config, policy)
+			resultChan <- csvResult{txns, problems, err}
 		}(filePath)
 	}
 
 	for i := 0; i < len(resolvedFilePaths); i++ {
-		select {
-		case txns := <-txnChan:
-			allTxns = append(allTxns, txns...)
-		case err := <-errChan:
-			return nil, err
+		result := <-resultChan
+		if result.err != nil {
+			return nil, result.err
 		}
+		allTxns = append(allTxns, result.txns...)
+		s.problems = append(s.problems, result.problems...)
 	}
 	sort.Slice(allTxns, func(i, j int) bool {
 		return allTxns[i].Date.Before(allTxns[j].Date)
 	})
+	sort.SliceStable(s.problems, func(i, j int) bool {
+		a, b := s.problems[i], s.problems[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	s.balances = openingBalances(allTxns, config)
 	return allTxns, nil
 }
 
-func readCsv(filePath string) ([]*data.Transaction, error) {
+func (s *CsvDataSource) Balances() []data.Balance {
+	return s.balances
+}
+
+func (s *CsvDataSource) Problems() []*data.ParseError {
+	return s.problems
+}
+
+func (s *CsvDataSource) WatchedFiles() []string {
+	if csvConfigFlag == "" {
+		return s.DataFiles()
+	}
+	return append([]string{csvConfigFlag}, s.DataFiles()...)
+}
+
+func (s *CsvDataSource) DataFiles() []string {
+	return csvFiles
+}
+
+// Opening balances from the config apply at the end of the day before the first transaction of the account
+// transactions must be sorted by date
+func openingBalances(transactions []*data.Transaction, config *config) []data.Balance {
+	if len(transactions) == 0 {
+		return nil
+	}
+	balances := []data.Balance{}
+	for account, amount := range config.OpeningBalances {
+		firstDate := transactions[0].Date
+		for _, t := range transactions {
+			if t.InvolvesAccount(account) {
+				firstDate = t.Date
+				break
+			}
+		}
+		balances = append(balances, data.Balance{
+			Date:        firstDate.AddDate(0, 0, -1),
+			AccountType: inferAccountType(account, config),
+			Account:     account,
+			Amount:      amount,
+			Currency:    config.DefaultCurrency,
+		})
+	}
+	return balances
+}
+
+// Read transactions from a CSV file, records that fail to parse are handled following the policy
+func readCsv(filePath string, config *config, policy data.ParseErrorPolicy) ([]*data.Transaction, []*data.ParseError, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file %s: %w", filePath, err)
+		return nil, nil, fmt.Errorf("failed to open file %s: %w", filePath, err)
 	}
 	defer file.Close()
 
-	config := getConfig()
 	reader := csv.NewReader(file)
 
 	header, err := reader.Read()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV header from %s: %w", filePath, err)
-	}
-
-	if len(config.ColumnIndexes) == 0 {
-		// Try to locate column index for each transaction field if not set
-		for index, col := range header {
-			if field, ok := config.Columns[col]; ok {
-				config.ColumnIndexes[field] = index
-			}
-		}
-		// Check each field has an index except for AccountType which can be inferred from Account
-		for _, field := range data.AllTransactionFields {
-			if _, ok := config.ColumnIndexes[field]; field != "AccountType" && !ok {
-				return nil, fmt.Errorf("failed to parse CSV from %s: unable to locate column for transaction field %s", filePath, field)
-			}
-		}
+		return nil, nil, fmt.Errorf("failed to read CSV header from %s: %w", filePath, err)
 	}
 
-	records, err := reader.ReadAll()
+	columns, err := locateColumns(header, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read all records from %s: %w", filePath, err)
+		return nil, nil, fmt.Errorf("failed to parse CSV from %s: %w", filePath, err)
 	}
 
-	txns := make([]*data.Transaction, len(records))
-	for i, rec := range records {
-		txns[i] = parseCsvRecord(rec, config)
+	txns := []*data.Transaction{}
+	problems := []*data.ParseError{}
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *data.ParseError
+		if csvErr, ok := err.(*csv.ParseError); ok {
+			// Malformed rows, e.g. with a wrong number of fields, can be skipped
+			parseErr = &data.ParseError{Line: csvErr.StartLine, Err: csvErr.Err}
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to read records from %s: %w", filePath, err)
+		} else {
+			var txn *data.Transaction
+			txn, parseErr = parseCsvRecord(rec, columns, config)
+			if parseErr == nil {
+				txn.File = filePath
+				txn.Line, _ = reader.FieldPos(0)
+				txns = append(txns, txn)
+				continue
+			}
+			parseErr.Line, _ = reader.FieldPos(0)
+		}
+		parseErr.File = filePath
+		if err := policy.Handle(parseErr, &problems); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	return txns, nil
+	return txns, problems, nil
 }
 
-func (s CsvDataSource) Preferred() bool {
+func (s *CsvDataSource) Preferred() bool {
 	return s.Enabled()
 }
 
-func (s CsvDataSource) Enabled() bool {
+func (s *CsvDataSource) Enabled() bool {
 	return len(csvFiles) > 0
 }
@@ -6,55 +6,118 @@ import (
 	"log"
 	"reflect"
 	"regexp"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
 )
 
-func parseCsvRecord(segments []string, config *config) *data.Transaction {
+// Where the values are in the records of a CSV file
+type csvColumns struct {
+	header []string
+	fields map[data.TransactionField]int
+	// Only located when the config has debit and credit columns
+	debit, credit int
+}
+
+// Locate the column of each transaction field from the config and the CSV header
+func locateColumns(header []string, config *config) (*csvColumns, error) {
+	columns := &csvColumns{
+		header: header,
+		fields: config.ColumnIndexes,
+	}
+	if len(columns.fields) == 0 {
+		// Try to locate column index for each transaction field if not set
+		columns.fields = map[data.TransactionField]int{}
+		for index, col := range header {
+			if field, ok := config.Columns[col]; ok {
+				columns.fields[field] = index
+			}
+		}
+	}
+	// Check each required field has an index
+	for _, field := range data.AllTransactionFields {
+		if _, ok := columns.fields[field]; config.fieldRequired(field) && !ok {
+			return nil, fmt.Errorf("unable to locate column for transaction field %s", field)
+		}
+	}
+
+	if config.hasDebitCredit() {
+		columns.debit = slices.Index(header, config.DebitColumn)
+		columns.credit = slices.Index(header, config.CreditColumn)
+		if columns.debit < 0 || columns.credit < 0 {
+			return nil, fmt.Errorf("unable to locate debit column %q and credit column %q", config.DebitColumn, config.CreditColumn)
+		}
+	}
+	return columns, nil
+}
+
+// Return the value of the column and its header, a missing value is an error
+func (c *csvColumns) value(segments []string, index int, field string) (string, string, *data.ParseError) {
+	column := fmt.Sprintf("#%d", index+1)
+	if index < len(c.header) {
+		column = c.header[index]
+	}
+	if index >= len(segments) {
+		return "", column, &data.ParseError{Column: column, Err: fmt.Errorf("missing value for %s", field)}
+	}
+	return segments[index], column, nil
+}
+
+// Parse a CSV record into a transaction, the returned error has the offending column and value but no file position
+func parseCsvRecord(segments []string, columns *csvColumns, config *config) (*data.Transaction, *data.ParseError) {
 	txn := data.Transaction{}
 	// Get reflect.Value of the struct pointer
 	v := reflect.ValueOf(&txn).Elem()
 
 	for _, f := range data.AllTransactionFields {
-		index, ok := config.ColumnIndexes[f]
+		index, ok := columns.fields[f]
 		if !ok {
-			if f == "AccountType" {
-				// Allow AccountType to be missing from CSV since we'd try to infer it from AccountName
-				continue
-			} else {
-				panic(fmt.Sprintf("parse CSV failed: transaction field %s not found", f))
-			}
+			// Allow optional fields to be missing from CSV, account types are inferred from account names
+			// Required fields are checked when locating columns
+			continue
+		}
+		value, column, parseErr := columns.value(segments, index, string(f))
+		if parseErr != nil {
+			return nil, parseErr
 		}
-		if index >= len(segments) {
-			panic(fmt.Sprintf("parse CSV failed: transaction field %s's not found", f))
+		fieldError := func(format string, a ...any) *data.ParseError {
+			return &data.ParseError{Column: column, Value: value, Err: fmt.Errorf(format, a...)}
 		}
-		value := segments[index]
 
 		field := v.FieldByName(string(f))
 		switch field.Kind() {
 		case reflect.String:
+			if value == "" && !config.fieldRequired(f) {
+				continue
+			}
 			if field.Type().Name() == "TransactionType" {
 				txnType, ok := config.TxnTypeMappings[strings.ToLower(value)]
 				if !ok {
-					panic(fmt.Sprintf("parse CSV failed: transaction type %s not recognized", value))
+					return nil, fieldError("transaction type not recognized")
 				}
 				field.Set(reflect.ValueOf(data.TransactionType(txnType)))
 			} else if field.Type().Name() == "AccountType" {
-				txnType, ok := config.AccountTypeMappings[strings.ToLower(value)]
+				acctType, ok := config.AccountTypeMappings[strings.ToLower(value)]
 				if !ok {
-					panic(fmt.Sprintf("parse CSV failed: account type %s not recognized", value))
+					return nil, fieldError("account type not recognized")
 				}
-				field.Set(reflect.ValueOf(data.TransactionType(txnType)))
+				field.Set(reflect.ValueOf(acctType))
 			} else {
 				field.SetString(value)
 			}
-		case reflect.Float64:
-			num, err := strconv.ParseFloat(value, 64)
+		case reflect.Int64:
+			if field.Type() != reflect.TypeOf(data.Money(0)) {
+				panic(fmt.Sprintf("parse CSV failed: unsupported field type %s", field.Type()))
+			}
+			// Amount may come with a currency, e.g. $12.00 or 12.00 EUR
+			amount, currency, err := data.ParseAmount(value)
 			if err != nil {
-				panic(fmt.Sprintf("parse CSV failed: %s is not float", value))
+				return nil, fieldError("not an amount")
+			}
+			field.Set(reflect.ValueOf(amount))
+			if currency != "" && txn.Currency == "" {
+				txn.Currency = currency
 			}
-			field.SetFloat(num)
 		case reflect.Struct:
 			// Special case: check if it's time.Time
 			if field.Type() == reflect.TypeOf(time.Time{}) {
@@ -67,40 +130,125 @@ func parseCsvRecord(segments []string, config *config) *data.Transaction {
 					}
 				}
 				if err != nil {
-					panic(fmt.Sprintf("parse CSV failed: unsupported date format %s", value))
+					return nil, fieldError("date does not match any of the date formats %s", strings.Join(config.DateFormats, ", "))
 				}
 				field.Set(reflect.ValueOf(parsed))
 			} else {
 				panic(fmt.Sprintf("parse CSV failed: unsupported struct type %s", field.Type()))
 			}
+		case reflect.Slice:
+			if f != "Tags" {
+				panic(fmt.Sprintf("parse CSV failed: unsupported slice field %s", f))
+			}
+			// Tags with a value, e.g. project:kitchen, go to the metadata
+			txn.Tags, txn.Metadata = data.ParseTagList(value)
 		default:
 			panic(fmt.Sprintf("parse CSV failed: unsupported field type %s", field.Kind()))
 		}
 	}
 
+	if config.SignedAmounts {
+		if parseErr := applyAmountSign(&txn, config); parseErr != nil {
+			return nil, parseErr
+		}
+	} else if config.hasDebitCredit() {
+		if parseErr := parseDebitCredit(&txn, segments, columns); parseErr != nil {
+			return nil, parseErr
+		}
+	}
+
+	if txn.Currency == "" {
+		txn.Currency = config.DefaultCurrency
+	}
+	if txn.Category == "" && txn.Type != data.Transfer {
+		txn.Category = data.Uncategorized
+	}
+
 	// Account type missing, try parsing from account name
 	if txn.AccountType == "" {
-		if len(config.AccountTypeFromName) == 0 {
-			panic("parse CSV failed: account name to type mapping is empty")
+		txn.AccountType = inferAccountType(txn.Account, config)
+	}
+	if txn.Type == data.Transfer && txn.ToAccount != "" && txn.ToAccountType == "" {
+		txn.ToAccountType = inferAccountType(txn.ToAccount, config)
+	}
+
+	if err := txn.Validate(); err != nil {
+		return nil, &data.ParseError{Err: fmt.Errorf("transaction is incomplete: %w", err)}
+	}
+	return &txn, nil
+}
+
+// Derive the transaction type from the sign of the amount unless the type is set, and make the amount positive
+func applyAmountSign(txn *data.Transaction, config *config) *data.ParseError {
+	if slices.Contains(config.InvertedSignAccounts, txn.Account) {
+		txn.Amount = -txn.Amount
+	}
+	if txn.Type == "" {
+		switch {
+		case txn.Amount > 0:
+			txn.Type = data.Income
+		case txn.Amount < 0:
+			txn.Type = data.Expense
+		default:
+			return &data.ParseError{Err: fmt.Errorf("zero amount has no transaction type")}
 		}
+	}
+	txn.Amount = txn.Amount.Abs()
+	return nil
+}
 
-		for accountNamePattern, accountType := range config.AccountTypeFromName {
-			re := regexp.MustCompile(accountNamePattern)
-			if re.MatchString(strings.ToLower(txn.Account)) {
-				txn.AccountType = accountType
-				break
-			}
+// Set the amount from whichever of the debit and credit columns has one, debits are expenses and credits are income
+// The transaction type is only derived if it is not set
+func parseDebitCredit(txn *data.Transaction, segments []string, columns *csvColumns) *data.ParseError {
+	type side struct {
+		name    string
+		index   int
+		txnType data.TransactionType
+	}
+	var found *side
+	for _, s := range []side{{"debit", columns.debit, data.Expense}, {"credit", columns.credit, data.Income}} {
+		value, column, parseErr := columns.value(segments, s.index, s.name)
+		if parseErr != nil {
+			return parseErr
+		}
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		amount, currency, err := data.ParseAmount(value)
+		if err != nil {
+			return &data.ParseError{Column: column, Value: value, Err: fmt.Errorf("not an amount")}
 		}
-		if txn.AccountType == "" {
-			// Default to credit card
-			log.Printf("Did not find a match in account name to type mapping for %s, default to credit card", txn.Account)
-			txn.AccountType = data.AcctCreditCard
+		// Some exports fill the other column with zero
+		if amount == 0 {
+			continue
 		}
+		if found != nil {
+			return &data.ParseError{Column: column, Value: value, Err: fmt.Errorf("both debit and credit have an amount")}
+		}
+		found = &s
+		txn.Amount = amount.Abs()
+		if currency != "" && txn.Currency == "" {
+			txn.Currency = currency
+		}
+	}
+	if found == nil {
+		return &data.ParseError{Err: fmt.Errorf("neither debit nor credit has an amount")}
 	}
+	if txn.Type == "" {
+		txn.Type = found.txnType
+	}
+	return nil
+}
 
-	if txn.IsValid() {
-		return &txn
-	} else {
-		panic(fmt.Sprintf("parse CSV failed: transaction is incomplete: %v", txn))
+func inferAccountType(account string, config *config) data.AccountType {
+	for accountNamePattern, accountType := range config.AccountTypeFromName {
+		// Patterns are validated when loading the config
+		re := regexp.MustCompile(accountNamePattern)
+		if re.MatchString(strings.ToLower(account)) {
+			return accountType
+		}
 	}
+	// Default to credit card
+	log.Printf("Did not find a match in account name to type mapping for %s, default to credit card", account)
+	return data.AcctCreditCard
 }
@@ -0,0 +1,75 @@
+package csv
+
+import (
+	"testing"
+
+	"cashd/internal/data"
+)
+
+func TestParseDebitCredit(t *testing.T) {
+	header := []string{"Date", "Account", "Description", "Category", "Type", "Debit", "Credit"}
+	config := &config{
+		Columns: map[string]data.TransactionField{
+			"Date": "Date", "Account": "Account", "Description": "Description", "Category": "Category", "Type": "Type",
+		},
+		DateFormats:         []string{"2006-01-02"},
+		TxnTypeMappings:     map[string]data.TransactionType{"refund": data.Income},
+		AccountTypeFromName: map[string]data.AccountType{"checking": data.AcctBankAccount},
+		DefaultCurrency:     data.DefaultCurrency,
+		DebitColumn:         "Debit",
+		CreditColumn:        "Credit",
+	}
+	columns, err := locateColumns(header, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		record       []string
+		wantType     data.TransactionType
+		wantAmount   data.Money
+		wantCurrency string
+		wantErr      bool
+	}{
+		{name: "debit", record: []string{"2024-01-10", "Checking", "Lunch", "Food", "", "12.50", ""}, wantType: data.Expense, wantAmount: 1250, wantCurrency: "$"},
+		{name: "credit", record: []string{"2024-01-10", "Checking", "Salary", "Pay", "", "", "3,000.00"}, wantType: data.Income, wantAmount: 300000, wantCurrency: "$"},
+		{name: "zero in the other column", record: []string{"2024-01-10", "Checking", "Lunch", "Food", "", "12.50", "0.00"}, wantType: data.Expense, wantAmount: 1250, wantCurrency: "$"},
+		{name: "negative debit", record: []string{"2024-01-10", "Checking", "Lunch", "Food", "", "-12.50", ""}, wantType: data.Expense, wantAmount: 1250, wantCurrency: "$"},
+		{name: "currency", record: []string{"2024-01-10", "Checking", "Lunch", "Food", "", "12.50 EUR", ""}, wantType: data.Expense, wantAmount: 1250, wantCurrency: "EUR"},
+		{name: "type column", record: []string{"2024-01-10", "Checking", "Shop", "Food", "refund", "12.50", ""}, wantType: data.Income, wantAmount: 1250, wantCurrency: "$"},
+		{name: "both columns", record: []string{"2024-01-10", "Checking", "Lunch", "Food", "", "12.50", "3.00"}, wantErr: true},
+		{name: "neither column", record: []string{"2024-01-10", "Checking", "Lunch", "Food", "", "", ""}, wantErr: true},
+		{name: "not an amount", record: []string{"2024-01-10", "Checking", "Lunch", "Food", "", "abc", ""}, wantErr: true},
+		{name: "missing credit column", record: []string{"2024-01-10", "Checking", "Lunch", "Food", "", "12.50"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txn, parseErr := parseCsvRecord(tt.record, columns, config)
+			if (parseErr != nil) != tt.wantErr {
+				t.Fatalf("parse error = %v, want error %v", parseErr, tt.wantErr)
+			} else if tt.wantErr {
+				return
+			}
+			if txn.Type != tt.wantType || txn.Amount != tt.wantAmount || txn.Currency != tt.wantCurrency {
+				t.Errorf("got %s %d %s, want %s %d %s", txn.Type, txn.Amount, txn.Currency, tt.wantType, tt.wantAmount, tt.wantCurrency)
+			}
+		})
+	}
+}
+
+func TestLocateDebitCreditColumns(t *testing.T) {
+	config := &config{
+		Columns:      map[string]data.TransactionField{"Date": "Date", "Account": "Account", "Description": "Description"},
+		DebitColumn:  "Debit",
+		CreditColumn: "Credit",
+	}
+	if _, err := locateColumns([]string{"Date", "Account", "Description", "Debit"}, config); err == nil {
+		t.Error("located columns without a credit column, want an error")
+	}
+	// Without debit and credit columns, an amount column and a type column are required
+	config.DebitColumn, config.CreditColumn = "", ""
+	if _, err := locateColumns([]string{"Date", "Account", "Description", "Debit", "Credit"}, config); err == nil {
+		t.Error("located columns without an amount column, want an error")
+	}
+}
@@ -0,0 +1,355 @@
+package data
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/pflag"
+)
+
+// Currency of amounts without an explicit currency
+const DefaultCurrency = "$"
+
+var reportingCurrencyFlag string
+var priceFileFlag string
+
+func init() {
+	pflag.StringVar(&reportingCurrencyFlag, "currency", "", "Currency to report amounts in, defaults to the most used currency")
+	pflag.StringVar(&priceFileFlag, "prices", "", "Price file with ledger style 'P' directives used for currency conversion")
+}
+
+// Return the currency of an ISO 4217 code, US dollars are DefaultCurrency to match other data sources
+func CurrencyOfCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" || code == "USD" {
+		return DefaultCurrency
+	}
+	return code
+}
+
+// Currency amounts are shown in, the currency of the data shown, see ConvertCurrency
+// Data is loaded in the background, so only the UI sets it when it shows the loaded data
+var reportingCurrency = DefaultCurrency
+
+func ReportingCurrency() string {
+	return reportingCurrency
+}
+
+func SetReportingCurrency(currency string) {
+	reportingCurrency = currency
+}
+
+// Price of one unit of Commodity in Currency on Date
+type Price struct {
+	Date      time.Time
+	Commodity string
+	Currency  string
+	Rate      float64
+}
+
+// PriceSource is implemented by data sources that also provide prices
+type PriceSource interface {
+	// Prices found while loading transactions
+	Prices() []Price
+}
+
+var (
+	// Amount with the currency before the number, e.g. $12.00, -$12, $-12, EUR 12.00, €12,50
+	prefixAmountRegex = regexp.MustCompile(`^(-)?\s*("[^"]+"|[^\d\s.,+\-"]+)\s*(-)?\s*(\d[\d,.]*|\.\d+)$`)
+	// Amount with the currency after the number, e.g. 12.00 EUR, -12 €, 1.234,56 EUR
+	suffixAmountRegex = regexp.MustCompile(`^(-)?\s*(\d[\d,.]*|\.\d+)\s*("[^"]+"|[^\d\s.,+\-"]+)?$`)
+	// Price directive, e.g. P 2024-01-01 EUR $1.08, the time is optional and ignored
+	priceDirectiveRegex = regexp.MustCompile(`^P\s+(\d{4}[-/.]\d{1,2}[-/.]\d{1,2})(?:\s+\d{1,2}:\d{2}(?::\d{2})?)?\s+("[^"]+"|\S+)\s+(.+)$`)
+)
+
+var priceDateFormats = []string{"2006-01-02", "2006/01/02", "2006.01.02", "2006-1-2", "2006/1/2", "2006.1.2"}
+
+// Parse an amount with an optional currency, e.g. $1,234.56, -$12, 12.00 EUR, EUR -12, €12,50
+// The currency is empty if the amount doesn't have one
+func ParseAmount(s string) (Money, string, error) {
+	numStr, currency, err := splitAmount(s)
+	if err != nil {
+		return 0, "", err
+	}
+	amount, err := ParseMoney(numStr)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to parse amount %q: %w", s, err)
+	}
+	return amount, currency, nil
+}
+
+// Parse a price with an optional currency like ParseAmount, keeping all decimal places
+func ParseRate(s string) (float64, string, error) {
+	numStr, currency, err := splitAmount(s)
+	if err != nil {
+		return 0, "", err
+	}
+	rate, err := strconv.ParseFloat(plainNumber(numStr), 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to parse price %q: %w", s, err)
+	}
+	return rate, currency, nil
+}
+
+// Split an amount into a signed number and the currency
+func splitAmount(s string) (string, string, error) {
+	s = strings.TrimSpace(s)
+	var negative bool
+	var numStr, currency string
+	if matches := prefixAmountRegex.FindStringSubmatch(s); matches != nil {
+		negative = matches[1] != "" || matches[3] != ""
+		currency = matches[2]
+		numStr = matches[4]
+	} else if matches := suffixAmountRegex.FindStringSubmatch(s); matches != nil {
+		negative = matches[1] != ""
+		numStr = matches[2]
+		currency = matches[3]
+	} else {
+		return "", "", fmt.Errorf("failed to parse amount %q", s)
+	}
+	if negative {
+		numStr = "-" + numStr
+	}
+	return numStr, strings.Trim(currency, `"`), nil
+}
+
+// Format amount with its currency, symbols go before the number and codes go after it
+// e.g. $1,234.56, €12.00, 12.00 EUR
+func FormatAmount(amount Money, currency string) string {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if r, size := utf8.DecodeRuneInString(currency); size == len(currency) && !unicode.IsLetter(r) {
+		return sign + currency + amount.Format()
+	}
+	return sign + amount.Format() + " " + currency
+}
+
+// Format amount in the reporting currency
+func FormatReportingAmount(amount Money) string {
+	return FormatAmount(amount, reportingCurrency)
+}
+
+// Parse a price directive, e.g. P 2024-01-01 EUR $1.08
+func ParsePriceDirective(line string) (Price, error) {
+	matches := priceDirectiveRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if matches == nil {
+		return Price{}, fmt.Errorf("invalid price directive %q", line)
+	}
+	var date time.Time
+	var err error
+	for _, f := range priceDateFormats {
+		if date, err = time.ParseInLocation(f, matches[1], time.Local); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return Price{}, fmt.Errorf("invalid date in price directive %q", line)
+	}
+	rate, currency, err := ParseRate(matches[3])
+	if err != nil {
+		return Price{}, err
+	}
+	return Price{
+		Date:      date,
+		Commodity: strings.Trim(matches[2], `"`),
+		Currency:  currency,
+		Rate:      rate,
+	}, nil
+}
+
+// Load prices from the file specified by --prices, other lines in the file are ignored
+func LoadPriceFile() ([]Price, error) {
+	if priceFileFlag == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(priceFileFlag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open price file %s: %w", priceFileFlag, err)
+	}
+	defer file.Close()
+
+	prices := []Price{}
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		if line := scanner.Text(); strings.HasPrefix(line, "P") {
+			p, err := ParsePriceDirective(line)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", priceFileFlag, lineNum, err)
+			}
+			prices = append(prices, p)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read price file %s: %w", priceFileFlag, err)
+	}
+	return prices, nil
+}
+
+// Prices indexed by commodity and currency, sorted by date
+type priceTable map[[2]string][]Price
+
+func newPriceTable(prices []Price) priceTable {
+	pt := priceTable{}
+	for _, p := range prices {
+		if p.Rate <= 0 {
+			continue
+		}
+		key := [2]string{p.Commodity, p.Currency}
+		pt[key] = append(pt[key], p)
+	}
+	for _, list := range pt {
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].Date.Before(list[j].Date)
+		})
+	}
+	return pt
+}
+
+// Return the rate to convert from one currency to another on the date
+// Conversion goes through at most one other currency if there is no price between the two
+func (pt priceTable) rate(from, to string, date time.Time) (float64, bool) {
+	if r, ok := pt.directRate(from, to, date); ok {
+		return r, true
+	}
+	for key := range pt {
+		for _, via := range key {
+			if via == from || via == to {
+				continue
+			}
+			r1, ok1 := pt.directRate(from, via, date)
+			r2, ok2 := pt.directRate(via, to, date)
+			if ok1 && ok2 {
+				return r1 * r2, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// Uses the latest price on or before the date, or the earliest price if there is none
+func (pt priceTable) directRate(from, to string, date time.Time) (float64, bool) {
+	if from == to {
+		return 1, true
+	}
+	if list, ok := pt[[2]string{from, to}]; ok {
+		return latestPrice(list, date).Rate, true
+	}
+	if list, ok := pt[[2]string{to, from}]; ok {
+		return 1 / latestPrice(list, date).Rate, true
+	}
+	return 0, false
+}
+
+func latestPrice(list []Price, date time.Time) Price {
+	i := sort.Search(len(list), func(i int) bool {
+		return list[i].Date.After(date)
+	})
+	if i == 0 {
+		return list[0]
+	}
+	return list[i-1]
+}
+
+// ConvertCurrency converts transactions and balances in place into the reporting currency set by --currency,
+// or the most used currency if not set. Original amounts are kept in OriginalAmount and OriginalCurrency.
+// Transactions and balances without a price to convert with are left out, so that totals don't add up
+// different currencies, and the left out transactions are returned as problems.
+// Return the converted transactions and balances, the problems and the reporting currency
+func ConvertCurrency(transactions []*Transaction, balances []Balance, prices []Price) ([]*Transaction, []Balance, []*ParseError, string) {
+	for _, t := range transactions {
+		if t.Currency == "" {
+			t.Currency = DefaultCurrency
+		}
+	}
+	for i := range balances {
+		if balances[i].Currency == "" {
+			balances[i].Currency = DefaultCurrency
+		}
+	}
+
+	reportingCurrency := reportingCurrencyFlag
+	if reportingCurrency == "" {
+		reportingCurrency = mostUsedCurrency(transactions)
+	}
+
+	pt := newPriceTable(prices)
+	missing := map[string]bool{}
+	rate := func(currency string, date time.Time) (float64, bool) {
+		rate, ok := pt.rate(currency, reportingCurrency, date)
+		if !ok && !missing[currency] {
+			log.Printf("No price to convert %s to %s, amounts in %s are left out\n", currency, reportingCurrency, currency)
+			missing[currency] = true
+		}
+		return rate, ok
+	}
+	converted := transactions[:0]
+	var problems []*ParseError
+	for _, t := range transactions {
+		if t.Currency == reportingCurrency {
+			converted = append(converted, t)
+			continue
+		}
+		rate, ok := rate(t.Currency, t.Date)
+		if !ok {
+			problems = append(problems, &ParseError{
+				File: t.File,
+				Line: t.Line,
+				Err:  fmt.Errorf("no price to convert %s %s to %s on %s", t.Amount, t.Currency, reportingCurrency, t.Date.Format(time.DateOnly)),
+			})
+			continue
+		}
+		t.OriginalAmount = t.Amount
+		t.OriginalCurrency = t.Currency
+		t.Amount = t.Amount.MulRate(rate)
+		t.Currency = reportingCurrency
+		for i := range t.Splits {
+			t.Splits[i].Amount = t.Splits[i].Amount.MulRate(rate)
+		}
+		converted = append(converted, t)
+	}
+	convertedBalances := balances[:0]
+	for _, b := range balances {
+		if b.Currency != reportingCurrency {
+			rate, ok := rate(b.Currency, b.Date)
+			if !ok {
+				continue
+			}
+			b.Amount = b.Amount.MulRate(rate)
+			b.Currency = reportingCurrency
+		}
+		convertedBalances = append(convertedBalances, b)
+	}
+	return converted, convertedBalances, problems, reportingCurrency
+}
+
+func mostUsedCurrency(transactions []*Transaction) string {
+	counts := map[string]int{}
+	for _, t := range transactions {
+		counts[t.Currency]++
+	}
+	result := DefaultCurrency
+	for c, n := range counts {
+		if n > counts[result] || (n == counts[result] && c < result) {
+			result = c
+		}
+	}
+	return result
+}
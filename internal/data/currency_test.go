@@ -0,0 +1,146 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		s            string
+		want         Money
+		wantCurrency string
+		wantErr      bool
+	}{
+		{s: "12.00", want: 1200},
+		{s: "$1,234.56", want: 123456, wantCurrency: "$"},
+		{s: "-$12", want: -1200, wantCurrency: "$"},
+		{s: "$-12", want: -1200, wantCurrency: "$"},
+		{s: "- $ 12", want: -1200, wantCurrency: "$"},
+		{s: "EUR 12.00", want: 1200, wantCurrency: "EUR"},
+		{s: "EUR -12", want: -1200, wantCurrency: "EUR"},
+		{s: "12.00 EUR", want: 1200, wantCurrency: "EUR"},
+		{s: "-12 €", want: -1200, wantCurrency: "€"},
+		{s: "€12.00", want: 1200, wantCurrency: "€"},
+		{s: "10 AAPL", want: 1000, wantCurrency: "AAPL"},
+		{s: `3 "VANGUARD 500"`, want: 300, wantCurrency: "VANGUARD 500"},
+		{s: ".5 USD", want: 50, wantCurrency: "USD"},
+		{s: "€12,50", want: 1250, wantCurrency: "€"},
+		{s: "-12,50 EUR", want: -1250, wantCurrency: "EUR"},
+		{s: "EUR 1.234,56", want: 123456, wantCurrency: "EUR"},
+		{s: "$1,234", want: 123400, wantCurrency: "$"},
+		{s: "", wantErr: true},
+		{s: "$", wantErr: true},
+		{s: "12 34", wantErr: true},
+		{s: "twelve", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, currency, err := ParseAmount(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAmount(%q) error = %v, want error %v", tt.s, err, tt.wantErr)
+		} else if got != tt.want || currency != tt.wantCurrency {
+			t.Errorf("ParseAmount(%q) = %d %q, want %d %q", tt.s, got, currency, tt.want, tt.wantCurrency)
+		}
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		s            string
+		want         float64
+		wantCurrency string
+		wantErr      bool
+	}{
+		{s: "$1.08695", want: 1.08695, wantCurrency: "$"},
+		{s: "1,234.5 EUR", want: 1234.5, wantCurrency: "EUR"},
+		{s: "0.5", want: 0.5},
+		{s: "1,08 €", want: 1.08, wantCurrency: "€"},
+		{s: "USD", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, currency, err := ParseRate(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRate(%q) error = %v, want error %v", tt.s, err, tt.wantErr)
+		} else if got != tt.want || currency != tt.wantCurrency {
+			t.Errorf("ParseRate(%q) = %v %q, want %v %q", tt.s, got, currency, tt.want, tt.wantCurrency)
+		}
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		amount   Money
+		currency string
+		want     string
+	}{
+		{123456, "$", "$1,234.56"},
+		{-1200, "$", "-$12.00"},
+		{1200, "", "$12.00"},
+		{1200, "€", "€12.00"},
+		{-1200, "EUR", "-12.00 EUR"},
+		{1000, "AAPL", "10.00 AAPL"},
+	}
+	for _, tt := range tests {
+		if got := FormatAmount(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("FormatAmount(%d, %q) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+		// Formatted amounts parse back
+		if amount, currency, err := ParseAmount(FormatAmount(tt.amount, tt.currency)); err != nil || amount != tt.amount {
+			t.Errorf("ParseAmount(FormatAmount(%d, %q)) = %d %q %v", tt.amount, tt.currency, amount, currency, err)
+		}
+	}
+}
+
+func TestParsePriceDirective(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    Price
+		wantErr bool
+	}{
+		{line: "P 2024-01-01 EUR $1.08", want: Price{Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local), Commodity: "EUR", Currency: "$", Rate: 1.08}},
+		{line: "P 2024/01/02 12:30 AAPL 150.25 USD", want: Price{Date: time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local), Commodity: "AAPL", Currency: "USD", Rate: 150.25}},
+		{line: "P 2024-01-01 EUR", wantErr: true},
+		{line: "2024-01-01 EUR $1.08", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePriceDirective(tt.line)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePriceDirective(%q) error = %v, want error %v", tt.line, err, tt.wantErr)
+		} else if !got.Date.Equal(tt.want.Date) || got.Commodity != tt.want.Commodity || got.Currency != tt.want.Currency || got.Rate != tt.want.Rate {
+			t.Errorf("ParsePriceDirective(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestConvertCurrency(t *testing.T) {
+	day := time.Date(2024, 1, 5, 0, 0, 0, 0, time.Local)
+	transactions := []*Transaction{
+		{Date: day, Amount: 10000, Currency: "$"},
+		{Date: day, Amount: 10000, Currency: "EUR", Splits: []Split{{Type: Expense, Category: "Food", Amount: 10000}}},
+		{Date: day, Amount: 10000, Currency: "GBP", File: "bank.journal", Line: 12},
+	}
+	balances := []Balance{
+		{Date: day, Account: "Checking", Amount: 5000},
+		{Date: day, Account: "London", Amount: 5000, Currency: "GBP"},
+	}
+	prices := []Price{{Date: day, Commodity: "EUR", Currency: "$", Rate: 1.1}}
+
+	reportingCurrencyFlag = "$"
+	defer func() { reportingCurrencyFlag = "" }()
+	converted, convertedBalances, problems, currency := ConvertCurrency(transactions, balances, prices)
+	if currency != "$" {
+		t.Errorf("reporting currency = %q, want $", currency)
+	}
+	if len(converted) != 2 || converted[1].Amount != 11000 || converted[1].Currency != "$" || converted[1].Splits[0].Amount != 11000 {
+		t.Errorf("converted transactions = %+v, want $100 and €100 as $110", converted)
+	}
+	if converted[1].OriginalAmount != 10000 || converted[1].OriginalCurrency != "EUR" {
+		t.Errorf("original amount = %s %s, want 100.00 EUR", converted[1].OriginalAmount, converted[1].OriginalCurrency)
+	}
+	if len(convertedBalances) != 1 || convertedBalances[0].Account != "Checking" {
+		t.Errorf("converted balances = %+v, want the balance in $ only", convertedBalances)
+	}
+	if len(problems) != 1 || problems[0].File != "bank.journal" || problems[0].Line != 12 {
+		t.Errorf("problems = %v, want the transaction in GBP", problems)
+	}
+}
@@ -1,10 +1,14 @@
 package data
 
 type DataSource interface {
+	// Name of the data source, recorded as the source of its transactions
+	Name() string
+
 	// Returned transactions must be ordered by date, from earliest to oldest
 	LoadTransactions() ([]*Transaction, error)
 
-	// Whether the data source is preferred
+	// Whether the data source is preferred, i.e. requested with a flag
+	// All enabled data sources are loaded, preferred ones first
 	Preferred() bool
 
 	// Whether the data source is enabled
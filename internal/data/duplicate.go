@@ -0,0 +1,243 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/pflag"
+)
+
+// DuplicatePolicy decides what happens to transactions that look like duplicates of each other
+type DuplicatePolicy string
+
+const (
+	// Keep all transactions
+	IgnoreDuplicates DuplicatePolicy = "off"
+	// Drop the duplicate of each pair
+	MergeDuplicates DuplicatePolicy = "merge"
+	// Keep both transactions of each pair until the user decides in the TUI
+	ReviewDuplicates DuplicatePolicy = "review"
+)
+
+var (
+	duplicatePolicyFlag    string
+	duplicateDays          int
+	duplicateMinSimilarity float64
+	duplicateDecisionsPath = filepath.Join(configDir, duplicateDecisionsFileName)
+)
+
+const duplicateDecisionsFileName = "duplicates.json"
+
+func init() {
+	pflag.StringVar(&duplicatePolicyFlag, "duplicates", string(ReviewDuplicates), "What to do with duplicate transactions: off, merge or review")
+	pflag.IntVar(&duplicateDays, "duplicate-days", 3, "Maximum number of days between the dates of duplicate transactions")
+	pflag.Float64Var(&duplicateMinSimilarity, "duplicate-similarity", 0.5, "Minimum similarity of the descriptions of duplicate transactions, from 0 to 1")
+}
+
+// Return the duplicate policy set by --duplicates
+func GetDuplicatePolicy() (DuplicatePolicy, error) {
+	switch p := DuplicatePolicy(strings.ToLower(strings.TrimSpace(duplicatePolicyFlag))); p {
+	case IgnoreDuplicates, MergeDuplicates, ReviewDuplicates:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid duplicate policy %q, expecting off, merge or review", duplicatePolicyFlag)
+	}
+}
+
+// DuplicatePair is two transactions that look like the same one imported twice
+type DuplicatePair struct {
+	// The transaction with the most information, e.g. a category instead of Uncategorized
+	Original *Transaction
+	// The transaction that is dropped when merging
+	Duplicate *Transaction
+	// Similarity of the descriptions, from 0 to 1
+	Similarity float64
+	// Identifies the pair across loads to remember the decision of the user,
+	// set when the pair is found as amounts can be converted afterwards
+	Key string
+}
+
+func transactionKey(t *Transaction) string {
+	return strings.Join([]string{
+		t.Source,
+		t.Date.Format(time.DateOnly),
+		t.Account,
+		t.Amount.String(),
+		t.Currency,
+		t.Description,
+	}, "/")
+}
+
+// DuplicateDecision is what the user decided for a duplicate pair
+type DuplicateDecision string
+
+const (
+	// Both transactions are real
+	KeepBoth DuplicateDecision = "keep"
+	// The duplicate is dropped
+	DropDuplicate DuplicateDecision = "drop"
+)
+
+// FindDuplicates returns pairs of transactions on the same account with the same amount,
+// dates within --duplicate-days and similar descriptions
+// Transactions must be sorted by date, and each transaction is in at most one pair
+func FindDuplicates(transactions []*Transaction) []*DuplicatePair {
+	maxDays := time.Duration(max(0, duplicateDays)) * 24 * time.Hour
+	paired := map[*Transaction]bool{}
+	pairs := []*DuplicatePair{}
+	for i, t := range transactions {
+		if paired[t] {
+			continue
+		}
+		var best *DuplicatePair
+		for _, other := range transactions[i+1:] {
+			if other.Date.Sub(t.Date) > maxDays {
+				break
+			}
+			if paired[other] || !sameMovement(t, other) {
+				continue
+			}
+			similarity := descriptionSimilarity(t.Description, other.Description)
+			if similarity >= duplicateMinSimilarity && (best == nil || similarity > best.Similarity) {
+				best = &DuplicatePair{Original: t, Duplicate: other, Similarity: similarity}
+			}
+		}
+		if best == nil {
+			continue
+		}
+		if moreInformative(best.Duplicate, best.Original) {
+			best.Original, best.Duplicate = best.Duplicate, best.Original
+		}
+		best.Key = transactionKey(best.Original) + "|" + transactionKey(best.Duplicate)
+		paired[best.Original] = true
+		paired[best.Duplicate] = true
+		pairs = append(pairs, best)
+	}
+	return pairs
+}
+
+// Whether both transactions move the same amount of money in and out of the same accounts
+func sameMovement(a, b *Transaction) bool {
+	return a.Type == b.Type &&
+		a.Account == b.Account &&
+		a.ToAccount == b.ToAccount &&
+		a.Amount == b.Amount &&
+		a.Currency == b.Currency
+}
+
+// Whether a has more information than b, so that a is kept when merging them
+func moreInformative(a, b *Transaction) bool {
+	aCategorized, bCategorized := a.Category != Uncategorized, b.Category != Uncategorized
+	if aCategorized != bCategorized {
+		return aCategorized
+	}
+	return len(a.Splits) > len(b.Splits)
+}
+
+// Return the similarity of two descriptions from 0 to 1
+// Bank statements often shorten or decorate descriptions, e.g. "AMAZON MKTPLACE 1234" for "Amazon",
+// so a description contained in the other is as similar as the same description
+func descriptionSimilarity(a, b string) float64 {
+	a, b = normalizeDescription(a), normalizeDescription(b)
+	if a == "" || b == "" {
+		return 0
+	} else if strings.Contains(a, b) || strings.Contains(b, a) {
+		return 1
+	}
+
+	// Dice coefficient of the letter pairs
+	aPairs := letterPairs(a)
+	bPairs := letterPairs(b)
+	if len(aPairs)+len(bPairs) == 0 {
+		return 0
+	}
+	common := 0
+	for pair, count := range aPairs {
+		common += min(count, bPairs[pair])
+	}
+	total := 0
+	for _, count := range aPairs {
+		total += count
+	}
+	for _, count := range bPairs {
+		total += count
+	}
+	return 2 * float64(common) / float64(total)
+}
+
+// Lowercase letters and digits, without spaces and punctuation
+func normalizeDescription(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func letterPairs(s string) map[string]int {
+	runes := []rune(s)
+	pairs := map[string]int{}
+	for i := 0; i+1 < len(runes); i++ {
+		pairs[string(runes[i:i+2])]++
+	}
+	return pairs
+}
+
+// RemoveDuplicates drops the duplicate of each pair from the transactions
+func RemoveDuplicates(transactions []*Transaction, pairs []*DuplicatePair) []*Transaction {
+	dropped := map[*Transaction]bool{}
+	for _, p := range pairs {
+		dropped[p.Duplicate] = true
+	}
+	kept := []*Transaction{}
+	for _, t := range transactions {
+		if !dropped[t] {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// LoadDuplicateDecisions returns the decisions of the user by pair key
+func LoadDuplicateDecisions() (map[string]DuplicateDecision, error) {
+	decisions := map[string]DuplicateDecision{}
+	data, err := os.ReadFile(duplicateDecisionsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return decisions, nil
+		}
+		return nil, fmt.Errorf("failed to read duplicate decisions file: %w", err)
+	}
+	if err := json.Unmarshal(data, &decisions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal duplicate decisions: %w", err)
+	}
+	return decisions, nil
+}
+
+// SaveDuplicateDecision remembers the decision of the user for a pair, so that it is applied on later loads
+func SaveDuplicateDecision(pair *DuplicatePair, decision DuplicateDecision) error {
+	decisions, err := LoadDuplicateDecisions()
+	if err != nil {
+		return err
+	}
+	decisions[pair.Key] = decision
+
+	data, err := json.MarshalIndent(decisions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal duplicate decisions: %w", err)
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory %s: %w", configDir, err)
+	}
+	if err := os.WriteFile(duplicateDecisionsPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write duplicate decisions to %s: %w", duplicateDecisionsPath, err)
+	}
+	return nil
+}
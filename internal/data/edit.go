@@ -0,0 +1,119 @@
+package data
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Number in an amount, e.g. 1,234.56 in $-1,234.56 or 1.234,56 in -1.234,56 EUR
+var amountNumberRegex = regexp.MustCompile(`\d(?:[\d,.]*\d)?`)
+
+// TransactionEdit is the fields of a transaction that can be changed in the TUI
+type TransactionEdit struct {
+	Date        time.Time
+	Category    string
+	Account     string
+	Description string
+	// In the currency of the data source, i.e. before conversion to the reporting currency
+	Amount Money
+	// Tags can only be added, tags with a value are metadata and can't be edited
+	Tags []string
+}
+
+// Editor is implemented by data sources that can write changed transactions back to their files
+type Editor interface {
+	// Change the transaction in the file it is loaded from, leaving the rest of the file as it is
+	EditTransaction(t *Transaction, edit TransactionEdit) error
+}
+
+// Return the fields of the transaction as they are in its data source
+func (t *Transaction) EditableFields() TransactionEdit {
+	amount := t.Amount
+	if t.IsConverted() {
+		amount = t.OriginalAmount
+	}
+	return TransactionEdit{
+		Date:        t.Date,
+		Category:    t.Category,
+		Account:     t.Account,
+		Description: t.Description,
+		Amount:      amount,
+		Tags:        slices.Clone(t.Tags),
+	}
+}
+
+// Replace the number of an amount with the magnitude of amount, keeping its sign, currency and number format
+// e.g. $-1,234.56 becomes $-2,000.00 and 12 EUR becomes 15 EUR
+func ReplaceAmountNumber(s string, amount Money) string {
+	loc := amountNumberRegex.FindStringIndex(s)
+	if loc == nil {
+		return s
+	}
+	like := s[loc[0]:loc[1]]
+	decimal, thousands := ".", ","
+	if decimalSeparator(like) == "," {
+		decimal, thousands = ",", "."
+	}
+	number := amount.Abs().String()
+	if strings.Contains(like, thousands) {
+		number = amount.Abs().Format()
+	}
+	if !strings.Contains(like, decimal) {
+		number = strings.TrimSuffix(number, ".00")
+	}
+	if decimal == "," {
+		number = strings.NewReplacer(".", ",", ",", ".").Replace(number)
+	}
+	return s[:loc[0]] + number + s[loc[1]:]
+}
+
+// Whether the edit changes any field of the transaction, dates are compared by day
+func (e TransactionEdit) Changes(t *Transaction) bool {
+	fields := t.EditableFields()
+	return e.Date.Format(time.DateOnly) != fields.Date.Format(time.DateOnly) ||
+		e.Category != fields.Category ||
+		e.Account != fields.Account ||
+		e.Description != fields.Description ||
+		e.Amount != fields.Amount ||
+		len(e.AddedTags(t)) > 0
+}
+
+// Return the tags of the edit the transaction doesn't have
+func (e TransactionEdit) AddedTags(t *Transaction) []string {
+	added := []string{}
+	for _, tag := range e.Tags {
+		if !slices.Contains(t.Tags, tag) && !slices.Contains(added, tag) {
+			added = append(added, tag)
+		}
+	}
+	return added
+}
+
+// Check the edit makes a valid transaction, transfers have no category to edit
+func (e TransactionEdit) Validate(t *Transaction) error {
+	if e.Account == "" {
+		return fmt.Errorf("account is required")
+	} else if e.Category == "" && !t.IsTransfer() {
+		return fmt.Errorf("category is required")
+	} else if e.Amount <= 0 {
+		return fmt.Errorf("amount must be positive")
+	} else if t.IsTransfer() && e.Category != "" {
+		return fmt.Errorf("transfers have no category")
+	} else if len(t.Splits) > 0 && e.Category != t.Category {
+		return fmt.Errorf("transactions with several categories can't be recategorized")
+	}
+	for _, tag := range t.Tags {
+		if !slices.Contains(e.Tags, tag) {
+			return fmt.Errorf("tags can't be removed: %s", tag)
+		}
+	}
+	for _, tag := range e.AddedTags(t) {
+		if tag == "" || strings.ContainsAny(tag, " \t:,;#") {
+			return fmt.Errorf("tags can't be empty or contain spaces, ':', ',', ';' or '#': %q", tag)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package data
+
+import "testing"
+
+func TestReplaceAmountNumber(t *testing.T) {
+	tests := []struct {
+		s      string
+		amount Money
+		want   string
+	}{
+		{"$-1,234.56", 200000, "$-2,000.00"},
+		{"12 EUR", 1500, "15 EUR"},
+		{"12 EUR", 1525, "15.25 EUR"},
+		{"-12.50", 100, "-1.00"},
+		{"€12,50", 1525, "€15,25"},
+		{"-1.234,56 EUR", 200000, "-2.000,00 EUR"},
+		{"1,234 USD", 200000, "2,000 USD"},
+		{"$-1,234.56 @ EUR 1.10", 200000, "$-2,000.00 @ EUR 1.10"},
+		{"EUR", 1000, "EUR"},
+	}
+	for _, tt := range tests {
+		if got := ReplaceAmountNumber(tt.s, tt.amount); got != tt.want {
+			t.Errorf("ReplaceAmountNumber(%q, %d) = %q, want %q", tt.s, tt.amount, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package ledger
+
+import (
+	"cashd/internal/data"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+func (l *LedgerDataSource) DefaultAppendFile() string {
+	return ledgerFilePath()
+}
+
+// Append the transaction to the journal as a two-posting entry, the account posting balances the other one
+func (l *LedgerDataSource) AppendTransaction(t *data.Transaction, file string) error {
+	if strings.Contains(t.Description, ";") {
+		return fmt.Errorf("journal descriptions can't contain ';'")
+	}
+	var typeStr, name string
+	amount := t.Amount
+	switch t.Type {
+	case data.Expense:
+		typeStr, name = expenses, t.Category
+	case data.Income:
+		// Income postings are negative, the money comes from the category
+		typeStr, name, amount = income, t.Category, -amount
+	case data.Transfer:
+		typeStr, name = postingAccountType(t.ToAccountType), t.ToAccount
+	default:
+		return fmt.Errorf("unsupported transaction type %s", t.Type)
+	}
+	var syntax journalSyntax
+	for _, n := range []string{name, t.Account} {
+		if err := syntax.CheckAccountName(n); err != nil {
+			return err
+		}
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to add to %s: %w", file, err)
+	}
+	newline := "\n"
+	if strings.Contains(string(content), "\r\n") {
+		newline = "\r\n"
+	}
+	lines := []string{
+		strings.TrimSpace(t.Date.Format(time.DateOnly) + " " + t.Description),
+		fmt.Sprintf("    %s:%s  %s", typeStr, name, data.FormatAmount(amount, t.Currency)),
+		fmt.Sprintf("    %s:%s", postingAccountType(t.AccountType), t.Account),
+	}
+	entry := strings.Join(lines, newline) + newline
+	// Entries are separated by an empty line
+	if text := string(content); text != "" {
+		switch {
+		case strings.HasSuffix(text, newline+newline):
+		case strings.HasSuffix(text, "\n"):
+			entry = newline + entry
+		default:
+			entry = newline + newline + entry
+		}
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to add to %s: %w", file, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(entry); err != nil {
+		return fmt.Errorf("failed to add to %s: %w", file, err)
+	}
+	return nil
+}
+
+// Credit cards are liabilities, other accounts are assets
+func postingAccountType(accountType data.AccountType) string {
+	if accountType == data.AcctCreditCard {
+		return liability
+	}
+	return assets
+}
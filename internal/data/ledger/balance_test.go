@@ -0,0 +1,58 @@
+package ledger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"cashd/internal/data"
+)
+
+func TestBalanceHistory(t *testing.T) {
+	j, err := parseJournal(strings.NewReader(`2024-01-05 Paycheck
+    assets:401k  $1000
+    assets:Checking  $3000
+    income:Salary  $-4000
+
+2024-01-10 Groceries
+    expenses:Food  $30
+    assets:Checking
+
+2024-01-12 Return
+    expenses:Household  $-5
+    assets:Checking
+
+2024-01-20 Interest
+    income:Interest  $-420
+    assets:Checking
+
+2024-01-25 Savings
+    assets:Savings  $200
+    assets:Checking
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := data.NewBalanceHistory(j.transactions, j.balances)
+
+	end := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		account string
+		date    time.Time
+		want    data.Money
+	}{
+		{"Checking", time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC), 300000},
+		{"Checking", time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC), 297500},
+		{"Checking", end, 319500},
+		{"401k", end, 100000},
+		{"Savings", end, 20000},
+	}
+	for _, tt := range tests {
+		if got := h.BalanceBefore(tt.account, tt.date); got != tt.want {
+			t.Errorf("balance of %s before %s = %s, want %s", tt.account, tt.date.Format(time.DateOnly), got, tt.want)
+		}
+	}
+	if assets, _ := h.NetWorthBefore(end); assets != 439500 {
+		t.Errorf("assets = %s, want %s", assets, data.Money(439500))
+	}
+}
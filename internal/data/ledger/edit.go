@@ -0,0 +1,247 @@
+package ledger
+
+import (
+	"cashd/internal/data"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+func (l *LedgerDataSource) EditTransaction(t *data.Transaction, edit data.TransactionEdit) error {
+	return EditEntry(t, edit, journalSyntax{})
+}
+
+// Syntax is what differs between the entries of journals and of other double-entry formats, e.g. beancount
+type Syntax interface {
+	// Return a posting line of an entry, with leading spaces removed, in journal syntax
+	// Return "" for other lines of the entry, e.g. metadata
+	JournalPosting(trimmed string) (string, error)
+	// Check that the account or category name can be written in a posting
+	CheckAccountName(name string) error
+	// Return where the amount starts in a posting line, -1 if it has none
+	AmountStart(line string) int
+	// Replace the description in the header of an entry
+	ReplaceDescription(line, desc string) (string, error)
+	// Add the tags to the header of an entry
+	AddTags(line string, tags []string) string
+}
+
+type journalSyntax struct{}
+
+func (journalSyntax) JournalPosting(trimmed string) (string, error) {
+	return trimmed, nil
+}
+
+// Account and category names become part of the posting account
+func (journalSyntax) CheckAccountName(name string) error {
+	if strings.Contains(name, ";") || strings.Contains(name, "  ") || strings.Contains(name, "\t") {
+		return fmt.Errorf("journal account names can't contain ';', tabs or double spaces: %s", name)
+	}
+	return nil
+}
+
+// The amount follows at least 2 spaces or a tab
+func (journalSyntax) AmountStart(line string) int {
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	if loc := postingSeparatorRegex.FindStringIndex(line[indent:]); loc != nil {
+		return indent + loc[1]
+	}
+	return -1
+}
+
+func (journalSyntax) ReplaceDescription(line, desc string) (string, error) {
+	return replaceJournalDescription(line, desc)
+}
+
+func (journalSyntax) AddTags(line string, tags []string) string {
+	return addJournalTags(line, tags)
+}
+
+// A posting of the entry being edited and the index of its line in the file
+type entryPosting struct {
+	line int
+	posting
+}
+
+// EditEntry patches the entry of the transaction in its file, e.g. a journal or a beancount file
+// Only the changed parts of the entry lines are rewritten, so comments, tags and alignment are kept
+func EditEntry(t *data.Transaction, edit data.TransactionEdit, syntax Syntax) error {
+	if t.File == "" || t.Line == 0 {
+		return fmt.Errorf("the file of the transaction is unknown, journals read with --ledger-bin can't be edited")
+	}
+	info, err := os.Stat(t.File)
+	if err != nil {
+		return fmt.Errorf("failed to edit %s: %w", t.File, err)
+	}
+	content, err := os.ReadFile(t.File)
+	if err != nil {
+		return fmt.Errorf("failed to edit %s: %w", t.File, err)
+	}
+	lines := strings.Split(string(content), "\n")
+	header := t.Line - 1
+	if header >= len(lines) || !headerHasDate(lines[header], t) {
+		return fmt.Errorf("the entry is no longer at %s:%d, reload and try again", t.File, t.Line)
+	}
+
+	postings := []entryPosting{}
+	for i := header + 1; i < len(lines); i++ {
+		line := strings.TrimSuffix(lines[i], "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || (line[0] != ' ' && line[0] != '\t') {
+			break
+		}
+		if strings.HasPrefix(trimmed, ";") || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		journalPosting, err := syntax.JournalPosting(trimmed)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", t.File, i+1, err)
+		} else if journalPosting == "" {
+			continue
+		}
+		pst, err := parsePosting(journalPosting)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", t.File, i+1, err)
+		}
+		postings = append(postings, entryPosting{line: i, posting: pst})
+	}
+
+	if edit.Account != t.Account {
+		if err := syntax.CheckAccountName(edit.Account); err != nil {
+			return err
+		}
+		pst := findPosting(postings, t.Account, assets, liability)
+		if pst == nil {
+			return fmt.Errorf("account %s is not in the entry at %s:%d, it may be set by a rule", t.Account, t.File, t.Line)
+		}
+		lines[pst.line] = replacePostingName(lines[pst.line], t.Account, edit.Account)
+	}
+	if edit.Category != t.Category {
+		if err := syntax.CheckAccountName(edit.Category); err != nil {
+			return err
+		}
+		pst := findPosting(postings, t.Category, income, expenses)
+		if pst == nil {
+			return fmt.Errorf("category %s is not in the entry at %s:%d, it may be set by a rule", t.Category, t.File, t.Line)
+		}
+		lines[pst.line] = replacePostingName(lines[pst.line], t.Category, edit.Category)
+	}
+	if edit.Amount != t.EditableFields().Amount {
+		if len(postings) != 2 {
+			return fmt.Errorf("the amount of an entry with %d postings can't be changed", len(postings))
+		}
+		for _, pst := range postings {
+			if pst.costCommodity != "" {
+				return fmt.Errorf("the amount of a posting with a cost can't be changed")
+			} else if pst.hasAmount {
+				lines[pst.line] = replacePostingAmount(lines[pst.line], edit.Amount, syntax)
+			}
+		}
+	}
+	if edit.Date.Format(time.DateOnly) != t.Date.Format(time.DateOnly) {
+		lines[header] = replaceHeaderDate(lines[header], edit)
+	}
+	if edit.Description != t.Description {
+		if lines[header], err = syntax.ReplaceDescription(lines[header], edit.Description); err != nil {
+			return err
+		}
+	}
+	if added := edit.AddedTags(t); len(added) > 0 {
+		lines[header] = syntax.AddTags(lines[header], added)
+	}
+
+	if err := os.WriteFile(t.File, []byte(strings.Join(lines, "\n")), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to edit %s: %w", t.File, err)
+	}
+	return nil
+}
+
+// Whether the line is still the header of the transaction, i.e. the file was not changed since it was loaded
+func headerHasDate(line string, t *data.Transaction) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	// Auxiliary dates, e.g. 2024-01-02=2024-01-05, are left out
+	dateStr, _, _ := strings.Cut(fields[0], "=")
+	d, err := parseJournalDate(dateStr)
+	return err == nil && d.Year() == t.Date.Year() && d.YearDay() == t.Date.YearDay()
+}
+
+// Return the first posting with the name and one of the types, nil if there is none
+func findPosting(postings []entryPosting, name string, types ...string) *entryPosting {
+	for i, pst := range postings {
+		if pst.accountOrCategory == name && (pst.typeStr == types[0] || pst.typeStr == types[1]) {
+			return &postings[i]
+		}
+	}
+	return nil
+}
+
+// Replace the name after the account type of a posting, and keep the amount aligned if there is room
+func replacePostingName(line, old, new string) string {
+	i := strings.Index(line, ":"+old)
+	if i < 0 {
+		return line
+	}
+	start, end := i+1, i+1+len(old)
+	rest := line[end:]
+	if spaces := len(rest) - len(strings.TrimLeft(rest, " ")); spaces >= 2 && spaces < len(strings.TrimRight(rest, "\r")) {
+		shift := utf8.RuneCountInString(new) - utf8.RuneCountInString(old)
+		rest = strings.Repeat(" ", max(2, spaces-shift)) + rest[spaces:]
+	}
+	return line[:start] + new + rest
+}
+
+// Replace the number of a posting amount, keeping its sign, commodity and number format
+func replacePostingAmount(line string, amount data.Money, syntax Syntax) string {
+	amountStart := syntax.AmountStart(line)
+	if amountStart < 0 {
+		return line
+	}
+	return line[:amountStart] + data.ReplaceAmountNumber(line[amountStart:], amount)
+}
+
+// Replace the date of the header, keeping its separator
+func replaceHeaderDate(line string, edit data.TransactionEdit) string {
+	end := strings.IndexAny(line, "= \t")
+	if end < 0 {
+		end = len(strings.TrimRight(line, "\r"))
+	}
+	sep := "-"
+	if i := strings.IndexAny(line[:end], "-/."); i >= 0 {
+		sep = line[i : i+1]
+	}
+	return edit.Date.Format("2006"+sep+"01"+sep+"02") + line[end:]
+}
+
+// Replace the description of a journal header, which ends at the comment
+func replaceJournalDescription(line, desc string) (string, error) {
+	if strings.Contains(desc, ";") {
+		return "", fmt.Errorf("journal descriptions can't contain ';'")
+	}
+	stripped := stripComment(strings.TrimSuffix(line, "\r"))
+	loc := transactionHeaderRegex.FindStringSubmatchIndex(stripped)
+	if loc == nil {
+		return "", fmt.Errorf("invalid transaction header %q", line)
+	}
+	if loc[4] < 0 {
+		// No description yet
+		return stripped + " " + desc + line[len(stripped):], nil
+	}
+	return line[:loc[4]] + desc + line[loc[5]:], nil
+}
+
+// Add the tags to the comment of a journal header, e.g. ; :vacation: becomes ; :vacation: :trip:
+func addJournalTags(line string, tags []string) string {
+	text := strings.TrimSuffix(line, "\r")
+	tagStr := ":" + strings.Join(tags, ":") + ":"
+	if strings.Contains(text, ";") {
+		text = strings.TrimRight(text, " \t") + " " + tagStr
+	} else {
+		text = strings.TrimRight(text, " \t") + "  ; " + tagStr
+	}
+	return text + line[len(strings.TrimSuffix(line, "\r")):]
+}
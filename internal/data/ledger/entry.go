@@ -0,0 +1,78 @@
+package ledger
+
+import (
+	"cashd/internal/data"
+	"time"
+)
+
+// Journal builds transactions from the entries of other double-entry formats, e.g. beancount,
+// the same way as from journal entries
+type Journal struct {
+	p journalParser
+}
+
+// Start a new entry, ending the current one, file and line are where the entry starts
+func (j *Journal) StartEntry(date time.Time, desc, file string, line int) {
+	j.p.endEntry()
+	j.p.inEntry = true
+	j.p.entryFile, j.p.entryLine = file, line
+	j.p.date = date
+	j.p.desc = desc
+	j.p.postings = nil
+	j.p.tags = nil
+}
+
+// Whether there is an entry to add postings and tags to
+func (j *Journal) InEntry() bool {
+	return j.p.inEntry
+}
+
+// Add a posting in journal syntax to the current entry, e.g. assets:Checking  $-10.00
+func (j *Journal) AddPosting(line string) error {
+	pst, err := parsePosting(line)
+	if err != nil {
+		return err
+	}
+	j.p.addPosting(pst)
+	return nil
+}
+
+// Add a tag to the current entry, value is empty for a tag without one
+func (j *Journal) AddTag(tag, value string) {
+	j.p.tags = append(j.p.tags, [2]string{tag, value})
+}
+
+// Emit the transactions of the current entry, if any
+func (j *Journal) EndEntry() {
+	j.p.endEntry()
+}
+
+func (j *Journal) AddPrice(price data.Price) {
+	j.p.prices = append(j.p.prices, price)
+}
+
+func (j *Journal) AddBalance(balance data.Balance) {
+	j.p.balances = append(j.p.balances, balance)
+}
+
+// Add a file or glob pattern to watch for changes
+func (j *Journal) AddFile(path string) {
+	j.p.files = append(j.p.files, path)
+}
+
+// Return the transactions of all entries sorted by date, ending the current entry
+func (j *Journal) Transactions() []*data.Transaction {
+	return sortedTransactions(j.p.finish())
+}
+
+func (j *Journal) Prices() []data.Price {
+	return j.p.prices
+}
+
+func (j *Journal) Balances() []data.Balance {
+	return j.p.balances
+}
+
+func (j *Journal) Files() []string {
+	return j.p.files
+}
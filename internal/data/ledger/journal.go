@@ -0,0 +1,89 @@
+package ledger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Matches include directive, e.g. "include 2024.journal" or hledger's "!include *.journal"
+var includeRegex = regexp.MustCompile(`^!?include\s+(.+)$`)
+
+// readJournal reads the journal file directly, without relying on ledger or hledger
+// Return transactions, prices from price directives and costs, and balances from assertions and opening entries
+func readJournal(path string) (*journal, error) {
+	p := &journalParser{}
+	if err := p.parseFile(path, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return p.finish(), nil
+}
+
+// Parse the journal file and all files it includes
+// including holds the files currently being parsed to detect include cycles
+func (p *journalParser) parseFile(path string, including map[string]bool) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve journal path %s: %w", path, err)
+	}
+	if including[absPath] {
+		return fmt.Errorf("journal %s includes itself", path)
+	}
+	including[absPath] = true
+	defer delete(including, absPath)
+
+	p.files = append(p.files, absPath)
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return fmt.Errorf("failed to read journal %s: %w", path, err)
+	}
+
+	for lineNum, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if matches := includeRegex.FindStringSubmatch(stripComment(line)); matches != nil {
+			// Directives always end the current entry
+			p.endEntry()
+			if err := p.parseIncludes(filepath.Dir(absPath), strings.TrimSpace(matches[1]), including); err != nil {
+				return fmt.Errorf("%s:%d: %w", path, lineNum+1, err)
+			}
+			continue
+		}
+		p.file, p.line = absPath, lineNum+1
+		if err := p.parseLine(line); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNum+1, err)
+		}
+	}
+	// An entry never continues into the next file
+	p.endEntry()
+	return nil
+}
+
+// Parse included files, pattern is relative to dir of the including file and can be a glob
+func (p *journalParser) parseIncludes(dir, pattern string, including map[string]bool) error {
+	if strings.HasPrefix(pattern, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			pattern = filepath.Join(home, pattern[2:])
+		}
+	}
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+	// Files added later that match the pattern are included too
+	p.files = append(p.files, pattern)
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to resolve include pattern %s: %w", pattern, err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("included file not found: %s", pattern)
+	}
+	for _, m := range matches {
+		if err := p.parseFile(m, including); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,16 +2,22 @@ package ledger
 
 import (
 	"cashd/internal/data"
-	"errors"
+	"fmt"
 	"os"
 	"os/exec"
+	"sort"
 
 	"github.com/spf13/pflag"
 )
 
-type LedgerDataSource struct{}
+type LedgerDataSource struct {
+	prices   []data.Price
+	balances []data.Balance
+	files    []string
+}
 
-var ledgerFilePath string = func() string {
+// Return the journal file from --ledger or the environment, flags are only parsed after package initialization
+func ledgerFilePath() string {
 	if ledgerFileFlag != "" {
 		return ledgerFileFlag
 	} else if env := os.Getenv("LEDGER_FILE"); env != "" {
@@ -21,52 +27,91 @@ var ledgerFilePath string = func() string {
 	} else {
 		return ""
 	}
-}()
+}
 
 var ledgerFileFlag string
+var ledgerBinFlag string
 
 func init() {
 	pflag.StringVar(&ledgerFileFlag, "ledger", "", "Ledger file path")
+	pflag.StringVar(&ledgerBinFlag, "ledger-bin", "", "Read the ledger file with 'ledger' or 'hledger' instead of the built-in parser")
+}
+
+func (l *LedgerDataSource) Name() string {
+	return "ledger"
 }
 
-func (l LedgerDataSource) LoadTransactions() ([]*data.Transaction, error) {
-	commands := []string{"ledger", "hledger"}
-
-	for _, cmd := range commands {
-		cmd := exec.Command(cmd, "-f", ledgerFilePath, "print")
-
-		stdout, err := cmd.StdoutPipe()
-		if err != nil {
-			return nil, err
-		}
-
-		if err := cmd.Start(); err != nil {
-			if errors.Is(err, exec.ErrNotFound) {
-				continue
-			} else {
-				return nil, err
-			}
-		}
-
-		// Stream output to parser
-		transactions, parseErr := parseJournal(stdout)
-		// Wait for command to complete
-		if err := cmd.Wait(); err != nil {
-			return nil, err
-		} else if parseErr != nil {
-			return nil, parseErr
-		} else {
-			return transactions, nil
-		}
+func (l *LedgerDataSource) LoadTransactions() ([]*data.Transaction, error) {
+	var j *journal
+	var err error
+	if ledgerBinFlag != "" {
+		j, err = printJournal(ledgerBinFlag)
+	} else {
+		j, err = readJournal(ledgerFilePath())
+	}
+	if err != nil {
+		return nil, err
 	}
+	l.prices = j.prices
+	l.balances = j.balances
+	l.files = j.files
+	if ledgerBinFlag != "" {
+		// Files included by the journal are only known to ledger or hledger
+		l.files = []string{ledgerFilePath()}
+	}
+	return sortedTransactions(j), nil
+}
+
+// Journal entries and included files are not necessarily in date order
+func sortedTransactions(j *journal) []*data.Transaction {
+	transactions := j.transactions
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].Date.Before(transactions[j].Date)
+	})
+	return transactions
+}
+
+func (l *LedgerDataSource) Prices() []data.Price {
+	return l.prices
+}
 
-	return nil, os.ErrNotExist
+func (l *LedgerDataSource) Balances() []data.Balance {
+	return l.balances
+}
+
+func (l *LedgerDataSource) WatchedFiles() []string {
+	return l.files
+}
+
+// Run "<bin> -f <file> print" and parse its output
+func printJournal(bin string) (*journal, error) {
+	cmd := exec.Command(bin, "-f", ledgerFilePath(), "print")
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", bin, err)
+	}
+
+	// Stream output to parser
+	j, parseErr := parseJournal(stdout)
+	// Wait for command to complete
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	} else if parseErr != nil {
+		return nil, parseErr
+	} else {
+		return j, nil
+	}
 }
 
-func (l LedgerDataSource) Preferred() bool {
+func (l *LedgerDataSource) Preferred() bool {
 	return ledgerFileFlag != ""
 }
 
-func (l LedgerDataSource) Enabled() bool {
-	return ledgerFilePath != ""
+func (l *LedgerDataSource) Enabled() bool {
+	return ledgerFilePath() != ""
 }
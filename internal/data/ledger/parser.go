@@ -8,7 +8,7 @@ import (
 	"log"
 	"math"
 	"regexp"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
 )
@@ -18,107 +18,569 @@ const (
 	liability = "liability"
 	expenses  = "expenses"
 	income    = "income"
+	equity    = "equity"
 )
 
-// ParseJournal reads the hledger journal file and parses transactions.
-func parseJournal(reader io.ReadCloser) ([]*data.Transaction, error) {
-	var transactions []*data.Transaction
-	scanner := bufio.NewScanner(reader)
-
-	// Regex to match transaction header: YYYY-MM-DD Description
-	// TODO: add support for '!' or '*' or '(code)' after date
+var (
+	// Regex to match transaction header: date, optional status ('*' or '!') and code, then description
+	// Secondary dates (date=date2) are accepted but ignored
 	// TODO: add support for '| note'
-	transactionHeaderRegex := regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\s+(.*)$`)
-	// Regex to match account line, with the following variations
-	// transaction type:category amount, e.g. expenses:Utilities 49.99, income:Cash Back $-47.11
-	// account type:account amount, e.g. liability:BoA 123 $-49.99, assets:BoA Checking $47.11
-	// TODO: add support for commodity other than '$'
-	accountLineRegex := regexp.MustCompile(`^\s+(.+):(.+)\s{2,}\$[-]?([\d,]+\.?\d*)$`)
-
-	var transactionDate time.Time
-	var transactionDesc string
-	var transactionType data.TransactionType
-	var transactionCategory string
-	var transactionAccount string
-	var accountType data.AccountType
-	var transactionAmount float64
-	var numPostings int
+	transactionHeaderRegex = regexp.MustCompile(`^(\d{4}[-/.]\d{1,2}[-/.]\d{1,2})(?:=\S+)?(?:\s+[*!])?(?:\s+\([^)]*\))?(?:\s+(.*))?$`)
+	// Account and amount in a posting are separated by at least 2 spaces or a tab
+	postingSeparatorRegex = regexp.MustCompile(`\s{2,}|\t`)
+	// Ledger tags in a comment, e.g. :vacation:travel:
+	commentTagsRegex = regexp.MustCompile(`(?:^|\s):((?:[^:\s]+:)+)`)
+	// Tag with a value in a comment, e.g. project: kitchen, the value ends at a comma as in hledger
+	commentTagValueRegex = regexp.MustCompile(`(?:^|[\s,])([^\s,:]+):[ \t]*([^,]*)`)
+)
+
+var journalDateFormats = []string{"2006-01-02", "2006/01/02", "2006.01.02", "2006-1-2", "2006/1/2", "2006.1.2"}
+
+type posting struct {
+	typeStr           string // account type (assets, liability) or transactionType (income, expense)
+	accountOrCategory string
+	amount            data.Money
+	commodity         string
+	hasAmount         bool
+	// Total cost of the posting, only set when the amount has a cost ('@' or '@@')
+	cost          data.Money
+	costCommodity string
+	// Balance of the account after the posting, only set when the posting has a balance assertion ('=')
+	assertion          data.Money
+	assertionCommodity string
+	hasAssertion       bool
+}
+
+// Return the amount used to balance the entry, which is the cost if there is one
+func (pst posting) weight() (data.Money, string) {
+	if pst.costCommodity != "" {
+		if pst.amount < 0 {
+			return -pst.cost, pst.costCommodity
+		}
+		return pst.cost, pst.costCommodity
+	}
+	return pst.amount, pst.commodity
+}
+
+// journal is everything read from journal files
+type journal struct {
+	transactions []*data.Transaction
+	prices       []data.Price
+	balances     []data.Balance
+	// Files read and include patterns, to watch for changes
+	files []string
+}
+
+// journalParser turns journal lines into transactions, one entry at a time
+type journalParser struct {
+	journal
+
+	inEntry   bool
+	inComment bool
+	date      time.Time
+	desc      string
+	postings  []posting
+	// Tags from the comments of the entry and their values, empty for tags without a value
+	tags [][2]string
+
+	// File and line being parsed, and where the current entry starts, the file is empty when reading ledger print
+	file      string
+	line      int
+	entryFile string
+	entryLine int
+}
+
+// ParseJournal reads the hledger journal file and parses transactions, prices and balances.
+func parseJournal(reader io.Reader) (*journal, error) {
+	p := &journalParser{}
+	if err := p.parse(reader, ""); err != nil {
+		return nil, err
+	}
+	return p.finish(), nil
+}
 
+// Parse all lines from reader, name is used in error messages only
+func (p *journalParser) parse(reader io.Reader, name string) error {
+	scanner := bufio.NewScanner(reader)
+	lineNum := 0
 	for scanner.Scan() {
-		line := scanner.Text()
+		lineNum++
+		if err := p.parseLine(scanner.Text()); err != nil {
+			if name != "" {
+				return fmt.Errorf("%s:%d: %w", name, lineNum, err)
+			}
+			return fmt.Errorf("line %d: %w", lineNum, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error parsing hledger journal: %w", err)
+	}
+	return nil
+}
 
-		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
-			// Skip empty lines and comments
-			continue
+func (p *journalParser) parseLine(line string) error {
+	trimmed := strings.TrimSpace(line)
+
+	if p.inComment {
+		if trimmed == "end comment" {
+			p.inComment = false
 		}
+		return nil
+	}
 
-		if matches := transactionHeaderRegex.FindStringSubmatch(line); len(matches) > 0 {
-			// This is a new transaction header
-			numPostings = 0
-			dateStr := matches[1]
-			description := matches[2]
+	if trimmed == "" {
+		// An empty line ends the current entry
+		p.endEntry()
+		return nil
+	}
 
-			parsedDate, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse date %q: %w", dateStr, err)
+	if line[0] == ' ' || line[0] == '\t' {
+		if p.inEntry && strings.HasPrefix(trimmed, ";") {
+			// Comments of the entry and its postings can have tags
+			p.parseCommentTags(trimmed[1:])
+			return nil
+		} else if !p.inEntry || strings.HasPrefix(trimmed, "#") {
+			// Skip lines that belong to directives or automated/periodic transactions
+			return nil
+		}
+		// This is a posting for the current transaction
+		pst, err := parsePosting(trimmed)
+		if err != nil {
+			return err
+		}
+		if _, comment, ok := strings.Cut(trimmed, ";"); ok {
+			// Tags of a posting apply to the whole transaction
+			p.parseCommentTags(comment)
+		}
+		p.addPosting(pst)
+		return nil
+	}
+
+	// Any unindented line ends the current entry
+	p.endEntry()
+
+	if strings.ContainsAny(line[:1], ";#*%|") {
+		// Skip comments
+		return nil
+	}
+	if strings.HasPrefix(line, "P ") {
+		price, err := data.ParsePriceDirective(stripComment(line))
+		if err != nil {
+			return err
+		}
+		p.prices = append(p.prices, price)
+		return nil
+	}
+	if trimmed == "comment" {
+		p.inComment = true
+		return nil
+	}
+
+	if matches := transactionHeaderRegex.FindStringSubmatch(stripComment(line)); len(matches) > 0 {
+		// This is a new transaction header
+		dateStr := matches[1]
+		parsedDate, err := parseJournalDate(dateStr)
+		if err != nil {
+			return err
+		}
+		p.inEntry = true
+		p.entryFile, p.entryLine = p.file, p.line
+		p.date = parsedDate
+		p.desc = strings.TrimSpace(matches[2])
+		p.postings = nil
+		p.tags = nil
+		if _, comment, ok := strings.Cut(line, ";"); ok {
+			p.parseCommentTags(comment)
+		}
+	} else {
+		log.Printf("skipping line: %s\n", line)
+	}
+	return nil
+}
+
+// Add a posting to the current entry, with the price its cost implies and its balance assertion
+func (p *journalParser) addPosting(pst posting) {
+	if pst.costCommodity != "" && pst.amount != 0 {
+		// A cost implies the price of the commodity on the date
+		p.prices = append(p.prices, data.Price{
+			Date:      p.date,
+			Commodity: pst.commodity,
+			Currency:  pst.costCommodity,
+			Rate:      pst.cost.Float64() / pst.amount.Abs().Float64(),
+		})
+	}
+	if pst.hasAssertion && (pst.typeStr == assets || pst.typeStr == liability) {
+		p.balances = append(p.balances, data.Balance{
+			Date:        p.date,
+			AccountType: accountType(pst),
+			Account:     pst.accountOrCategory,
+			Amount:      pst.assertion,
+			Currency:    pst.assertionCommodity,
+		})
+	}
+	p.postings = append(p.postings, pst)
+}
+
+// Emit the transaction being parsed, if any
+func (p *journalParser) endEntry() {
+	if !p.inEntry {
+		return
+	}
+	p.inEntry = false
+
+	if balances, ok := p.openingBalances(); ok {
+		p.balances = append(p.balances, balances...)
+		return
+	}
+	transactions := p.buildTransactions()
+	if len(transactions) == 0 {
+		log.Printf("skipping transaction: %s %s\n", p.date.Format(time.DateOnly), p.desc)
+		return
+	}
+	for _, t := range transactions {
+		for _, tag := range p.tags {
+			t.AddTag(tag[0], tag[1])
+		}
+		if p.entryFile != "" {
+			t.File, t.Line = p.entryFile, p.entryLine
+		}
+		p.transactions = append(p.transactions, t)
+	}
+}
+
+// Build transactions from the postings of the current entry
+// Income and expense postings become category splits, with signed amounts so that e.g. a refund reduces its category
+// The splits make up a single transaction, and other asset or liability postings are transfers from or to its account,
+// e.g. a paycheck paid into a checking account and a 401k is an income and a transfer from checking to the 401k
+// An entry that only moves money between two accounts is a transfer
+// Return nil if the entry has no account or no category
+func (p *journalParser) buildTransactions() []*data.Transaction {
+	if !p.balancePostings() {
+		return nil
+	}
+
+	var currency string
+	splits := []data.Split{}
+	accountPostings := []posting{}
+	for _, pst := range p.postings {
+		switch pst.typeStr {
+		case expenses, income:
+			amount, commodity := pst.amount, pst.commodity
+			if currency == "" {
+				// The first category decides the currency of the transaction
+				currency = commodity
+			} else if commodity != currency {
+				if pst.costCommodity != currency {
+					log.Printf("skipping transaction with mixed currencies %s and %s\n", currency, commodity)
+					return nil
+				}
+				amount, _ = pst.weight()
 			}
-			transactionDate = parsedDate
-			transactionDesc = description
-		} else if matches := accountLineRegex.FindStringSubmatch(line); len(matches) > 0 {
-			// This is a posting for the current transaction
-			numPostings++
-			typeStr := strings.TrimSpace(matches[1])             // account type (assets, liability) or transactionType (income, expense)
-			accountOrCategory := strings.TrimSpace(matches[2])   // account or cateogory
-			amountStr := strings.ReplaceAll(matches[3], ",", "") // Remove commas for parsing
-			amountStr = strings.ReplaceAll(amountStr, "$", "")   // Remove dollar sign
-
-			amount, err := strconv.ParseFloat(amountStr, 64)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse amount %q: %w", amountStr, err)
+			// Expenses are positive postings and income negative ones
+			split := data.Split{Category: pst.accountOrCategory, Type: data.Expense, Amount: amount}
+			if pst.typeStr == income {
+				split.Type, split.Amount = data.Income, -amount
 			}
-			transactionAmount = math.Abs(amount)
-
-			switch typeStr {
-			case expenses:
-				transactionType = data.Expense
-				transactionCategory = accountOrCategory
-			case income:
-				transactionType = data.Income
-				transactionCategory = accountOrCategory
-			case assets:
-				transactionAccount = accountOrCategory
-				if strings.ToLower(transactionAccount) == "cash" {
-					accountType = data.AcctCash
-				} else {
-					accountType = data.AcctBankAccount
-				}
-			case liability:
-				transactionAccount = accountOrCategory
-				accountType = data.AcctCreditCard
+			splits = append(splits, split)
+		case assets, liability:
+			accountPostings = append(accountPostings, pst)
+		}
+	}
+	if len(accountPostings) == 0 {
+		return nil
+	}
+
+	if len(splits) == 0 {
+		if len(accountPostings) != 2 || accountPostings[0].amount == 0 {
+			return nil
+		}
+		// Money leaves the account with the negative posting
+		from, to := accountPostings[0], accountPostings[1]
+		if from.amount > 0 {
+			from, to = to, from
+		}
+		return []*data.Transaction{p.transfer(from, to, from.amount.Abs(), from.commodity)}
+	}
+
+	// What the categories move in or out of the accounts
+	var net data.Money
+	for _, s := range splits {
+		if s.Type == data.Income {
+			net += s.Amount
+		} else {
+			net -= s.Amount
+		}
+	}
+	if net == 0 {
+		return nil
+	}
+	// What flows into each account, a single account takes the whole entry
+	flows := []data.Money{net}
+	if len(accountPostings) > 1 {
+		flows = []data.Money{}
+		var total data.Money
+		for _, pst := range accountPostings {
+			amount, commodity := pst.weight()
+			if commodity != currency {
+				log.Printf("skipping transaction with mixed currencies %s and %s\n", currency, commodity)
+				return nil
 			}
+			flows = append(flows, amount)
+			total += amount
+		}
+		if total != net {
+			return nil
+		}
+	}
 
-			// Currently only handling transactions that involves exactly 2 postings
-			// TODO: add support for more than 2 postings
-			if numPostings == 2 {
-				t := data.Transaction{
-					Date:        transactionDate,
-					Type:        transactionType,
-					Account:     transactionAccount,
-					AccountType: accountType,
-					Category:    transactionCategory,
-					Amount:      transactionAmount,
-					Description: transactionDesc,
-				}
-				transactions = append(transactions, &t)
+	// The categories go to the account with the largest flow in their direction, e.g. the checking account
+	// a paycheck is paid into, and the rest moves from or to the other accounts as transfers
+	main := -1
+	for i, flow := range flows {
+		if (flow < 0) == (net < 0) && flow != 0 && (main < 0 || flow.Abs() > flows[main].Abs()) {
+			main = i
+		}
+	}
+	transactions := []*data.Transaction{p.categoryTransaction(accountPostings[main], net, splits, currency)}
+	transfers := slices.Clone(flows)
+	transfers[main] -= net
+	for i, from := range accountPostings {
+		for j, to := range accountPostings {
+			if transfers[i] < 0 && transfers[j] > 0 {
+				amount := min(-transfers[i], transfers[j])
+				transactions = append(transactions, p.transfer(from, to, amount, currency))
+				transfers[i] += amount
+				transfers[j] -= amount
 			}
+		}
+	}
+	return transactions
+}
+
+// Build an income or expense transaction of the given account, flow is what goes into it
+func (p *journalParser) categoryTransaction(pst posting, flow data.Money, splits []data.Split, currency string) *data.Transaction {
+	t := &data.Transaction{
+		Date:        p.date,
+		Description: p.desc,
+		Type:        data.Expense,
+		Account:     pst.accountOrCategory,
+		AccountType: accountType(pst),
+		Amount:      -flow,
+		Currency:    currency,
+		Splits:      splits,
+	}
+	if flow > 0 {
+		t.Type, t.Amount = data.Income, flow
+	}
+	categories := []string{}
+	for _, s := range splits {
+		if !slices.Contains(categories, s.Category) {
+			categories = append(categories, s.Category)
+		}
+	}
+	t.Category = strings.Join(categories, ", ")
+	if len(splits) == 1 && splits[0].Type == t.Type && splits[0].Amount == t.Amount {
+		// A single split is the transaction itself
+		t.Splits = nil
+	}
+	return t
+}
+
+// Build a transfer between two account postings
+func (p *journalParser) transfer(from, to posting, amount data.Money, currency string) *data.Transaction {
+	return &data.Transaction{
+		Date:          p.date,
+		Description:   p.desc,
+		Type:          data.Transfer,
+		Account:       from.accountOrCategory,
+		AccountType:   accountType(from),
+		ToAccount:     to.accountOrCategory,
+		ToAccountType: accountType(to),
+		Amount:        amount,
+		Currency:      currency,
+	}
+}
+
+// Opening balance entries move money between equity and accounts, without any income or expense
+// Return the balances they set at the end of the previous day, and false if the entry isn't one
+func (p *journalParser) openingBalances() ([]data.Balance, bool) {
+	hasEquity := false
+	for _, pst := range p.postings {
+		switch pst.typeStr {
+		case equity:
+			hasEquity = true
+		case expenses, income:
+			return nil, false
+		}
+	}
+	if !hasEquity {
+		return nil, false
+	}
+	if !p.balancePostings() {
+		// e.g. balance assignments, which are already kept as assertions
+		return nil, true
+	}
+
+	balances := []data.Balance{}
+	for _, pst := range p.postings {
+		if pst.typeStr == assets || pst.typeStr == liability {
+			balances = append(balances, data.Balance{
+				Date:        p.date.AddDate(0, 0, -1),
+				AccountType: accountType(pst),
+				Account:     pst.accountOrCategory,
+				Amount:      pst.amount,
+				Currency:    pst.commodity,
+			})
+		}
+	}
+	return balances, true
+}
+
+func accountType(pst posting) data.AccountType {
+	return AccountType(pst.typeStr, pst.accountOrCategory)
+}
+
+// AccountType returns the type of an account from its journal account type, e.g. liability:BoA is a credit card
+func AccountType(typeStr, account string) data.AccountType {
+	if typeStr == liability {
+		return data.AcctCreditCard
+	} else if strings.ToLower(account) == "cash" {
+		return data.AcctCash
+	} else {
+		return data.AcctBankAccount
+	}
+}
+
+// Fill in the elided amount, return false if the postings can not be balanced
+func (p *journalParser) balancePostings() bool {
+	elided := -1
+	sums := map[string]data.Money{}
+	for i, pst := range p.postings {
+		if pst.hasAmount {
+			amount, commodity := pst.weight()
+			sums[commodity] += amount
+		} else if elided >= 0 {
+			// At most one posting can omit its amount
+			return false
 		} else {
-			log.Printf("skipping line: %s\n", line)
+			elided = i
 		}
 	}
+	if elided >= 0 {
+		if len(sums) != 1 {
+			// Can't tell which commodity balances the entry
+			return false
+		}
+		for commodity, sum := range sums {
+			p.postings[elided].amount = -sum
+			p.postings[elided].commodity = commodity
+		}
+		p.postings[elided].hasAmount = true
+	}
+	return true
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error parsing hledger journal: %w", err)
+// Finish parsing and return everything read
+func (p *journalParser) finish() *journal {
+	p.endEntry()
+	return &p.journal
+}
+
+// Parse a posting line with leading spaces removed, with the following variations
+// transaction type:category amount, e.g. expenses:Utilities $49.99, income:Cash Back $-47.11
+// account type:account amount, e.g. liability:BoA 123 $-49.99, assets:BoA Checking $47.11
+// The amount can be omitted on one posting, in which case it balances the others
+func parsePosting(line string) (posting, error) {
+	line = stripComment(line)
+	var accountStr, amountStr string
+	if loc := postingSeparatorRegex.FindStringIndex(line); loc != nil {
+		accountStr = line[:loc[0]]
+		amountStr = strings.TrimSpace(line[loc[1]:])
+	} else {
+		accountStr = line
+	}
+
+	var pst posting
+	typeStr, accountOrCategory, found := strings.Cut(accountStr, ":")
+	if !found {
+		// Top-level account, e.g. "equity"
+		typeStr = accountStr
 	}
+	pst.typeStr = strings.TrimSpace(typeStr)
+	pst.accountOrCategory = strings.TrimSpace(accountOrCategory)
 
-	return transactions, nil
+	// Balance assertion, e.g. $10 = $500, or a balance assignment without the amount, e.g. = $500
+	if before, assertionStr, found := strings.Cut(amountStr, "="); found {
+		amountStr = strings.TrimSpace(before)
+		// "==" asserts the total of all commodities, which is treated the same
+		assertionStr, _, _ = strings.Cut(strings.TrimPrefix(assertionStr, "="), "@")
+		assertion, commodity, err := data.ParseAmount(assertionStr)
+		if err != nil {
+			return pst, err
+		}
+		pst.assertion = assertion
+		pst.assertionCommodity = commodity
+		pst.hasAssertion = true
+	}
+	// Cost is either per unit ('@') or total ('@@')
+	amountStr, costStr, hasCost := strings.Cut(amountStr, "@")
+	if amountStr = strings.TrimSpace(amountStr); amountStr != "" {
+		amount, commodity, err := data.ParseAmount(amountStr)
+		if err != nil {
+			return pst, err
+		}
+		pst.amount = amount
+		pst.commodity = commodity
+		pst.hasAmount = true
+	}
+	if hasCost && pst.hasAmount {
+		totalCost, isTotal := strings.CutPrefix(costStr, "@")
+		if isTotal {
+			cost, commodity, err := data.ParseAmount(totalCost)
+			if err != nil {
+				return pst, err
+			}
+			pst.cost = cost.Abs()
+			pst.costCommodity = commodity
+		} else {
+			unitCost, commodity, err := data.ParseRate(totalCost)
+			if err != nil {
+				return pst, err
+			}
+			pst.cost = pst.amount.Abs().MulRate(math.Abs(unitCost))
+			pst.costCommodity = commodity
+		}
+	}
+	return pst, nil
+}
+
+func parseJournalDate(s string) (time.Time, error) {
+	for _, f := range journalDateFormats {
+		if d, err := time.ParseInLocation(f, s, time.Local); err == nil {
+			return d, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("failed to parse date %q", s)
+}
+
+// Add the tags in a comment to the current entry, e.g. :vacation:travel: or project: kitchen, client: acme
+func (p *journalParser) parseCommentTags(comment string) {
+	for _, matches := range commentTagsRegex.FindAllStringSubmatch(comment, -1) {
+		for _, tag := range strings.Split(strings.TrimSuffix(matches[1], ":"), ":") {
+			p.tags = append(p.tags, [2]string{tag, ""})
+		}
+	}
+	comment = commentTagsRegex.ReplaceAllString(comment, " ")
+	for _, matches := range commentTagValueRegex.FindAllStringSubmatch(comment, -1) {
+		p.tags = append(p.tags, [2]string{matches[1], strings.TrimSpace(matches[2])})
+	}
+}
+
+// Remove a trailing "; comment" from a line
+func stripComment(line string) string {
+	if i := strings.Index(line, ";"); i >= 0 {
+		return strings.TrimRightFunc(line[:i], func(r rune) bool { return r == ' ' || r == '\t' })
+	}
+	return line
 }
@@ -0,0 +1,142 @@
+package ledger
+
+import (
+	"strings"
+	"testing"
+
+	"cashd/internal/data"
+)
+
+func TestBuildTransactions(t *testing.T) {
+	type leg struct {
+		txType    data.TransactionType
+		account   string
+		toAccount string
+		amount    data.Money
+		category  string
+		splits    []data.Split
+	}
+	tests := []struct {
+		name    string
+		journal string
+		want    []leg
+	}{
+		{
+			name: "expense",
+			journal: `2024-01-10 Groceries
+    expenses:Food  $30
+    assets:Checking
+`,
+			want: []leg{{data.Expense, "Checking", "", 3000, "Food", nil}},
+		},
+		{
+			name: "refund",
+			journal: `2024-01-12 Return
+    expenses:Household  $-5
+    assets:Checking
+`,
+			want: []leg{{data.Income, "Checking", "", 500, "Household", []data.Split{
+				{Type: data.Expense, Category: "Household", Amount: -500},
+			}}},
+		},
+		{
+			name: "purchase with a refund",
+			journal: `2024-01-12 Exchange
+    expenses:Household  $30
+    expenses:Household  $-5
+    assets:Checking
+`,
+			want: []leg{{data.Expense, "Checking", "", 2500, "Household", []data.Split{
+				{Type: data.Expense, Category: "Household", Amount: 3000},
+				{Type: data.Expense, Category: "Household", Amount: -500},
+			}}},
+		},
+		{
+			name: "split paycheck",
+			journal: `2024-01-05 Paycheck
+    assets:401k  $1000
+    assets:Checking  $3000
+    income:Salary  $-4000
+`,
+			want: []leg{
+				{data.Income, "Checking", "", 400000, "Salary", nil},
+				{data.Transfer, "Checking", "401k", 100000, "", nil},
+			},
+		},
+		{
+			name: "paycheck with taxes",
+			journal: `2024-01-05 Paycheck
+    assets:401k  $1000
+    assets:Checking  $3000
+    expenses:Taxes  $1000
+    income:Salary  $-5000
+`,
+			want: []leg{
+				{data.Income, "Checking", "", 400000, "Taxes, Salary", []data.Split{
+					{Type: data.Expense, Category: "Taxes", Amount: 100000},
+					{Type: data.Income, Category: "Salary", Amount: 500000},
+				}},
+				{data.Transfer, "Checking", "401k", 100000, "", nil},
+			},
+		},
+		{
+			name: "groceries paid from two accounts",
+			journal: `2024-01-10 Groceries
+    expenses:Food  $100
+    assets:Cash  $-40
+    assets:Checking  $-60
+`,
+			want: []leg{
+				{data.Expense, "Checking", "", 10000, "Food", nil},
+				{data.Transfer, "Cash", "Checking", 4000, "", nil},
+			},
+		},
+		{
+			name: "shares bought with a fee",
+			journal: `2024-02-01 Buy
+    assets:Broker  10 AAPL @ $100
+    expenses:Fees  $5
+    assets:Checking  $-1005
+`,
+			want: []leg{
+				{data.Expense, "Checking", "", 500, "Fees", nil},
+				{data.Transfer, "Checking", "Broker", 100000, "", nil},
+			},
+		},
+		{
+			name: "transfer",
+			journal: `2024-02-01 Savings
+    assets:Savings  $200
+    assets:Checking
+`,
+			want: []leg{{data.Transfer, "Checking", "Savings", 20000, "", nil}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j, err := parseJournal(strings.NewReader(tt.journal))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(j.transactions) != len(tt.want) {
+				t.Fatalf("got %d transactions, want %d", len(j.transactions), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := j.transactions[i]
+				if got.Type != want.txType || got.Account != want.account || got.ToAccount != want.toAccount || got.Amount != want.amount ||
+					got.Category != want.category {
+					t.Errorf("transaction %d = %s %s %q %d %q, want %s %s %q %d %q", i, got.Type, got.Account, got.ToAccount, got.Amount,
+						got.Category, want.txType, want.account, want.toAccount, want.amount, want.category)
+				}
+				if len(got.Splits) != len(want.splits) {
+					t.Fatalf("transaction %d has splits %v, want %v", i, got.Splits, want.splits)
+				}
+				for k, s := range want.splits {
+					if got.Splits[k] != s {
+						t.Errorf("transaction %d split %d = %v, want %v", i, k, got.Splits[k], s)
+					}
+				}
+			}
+		})
+	}
+}
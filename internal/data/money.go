@@ -1,25 +1,172 @@
 package data
 
 import (
+	"bytes"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 )
 
-func FormatMoney(amount float64) string {
-	s := fmt.Sprintf("%.2f", amount)
-	parts := strings.Split(s, ".")
-	integer := parts[0]
-	decimal := parts[1]
+// Money is an exact amount in cents, so that sums don't drift like floats do
+// Being an integer, amounts can be added, subtracted and compared with the usual operators
+type Money int64
+
+// Number of decimal places kept by Money
+const moneyDecimals = 2
+
+const moneyScale = 100
+
+// Parse a decimal number with an optional sign and thousands separators, e.g. 1,234.56 or -12.5
+// A decimal comma is accepted too, e.g. 12,50 or 1.234,56, see decimalSeparator
+// Digits after the 2nd decimal place are rounded half away from zero
+func ParseMoney(s string) (Money, error) {
+	str := plainNumber(strings.TrimSpace(s))
+	negative := false
+	if rest, ok := strings.CutPrefix(str, "-"); ok {
+		negative = true
+		str = rest
+	} else if rest, ok := strings.CutPrefix(str, "+"); ok {
+		str = rest
+	}
+
+	integer, fraction, _ := strings.Cut(str, ".")
+	if integer == "" && fraction == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if integer == "" {
+		integer = "0"
+	}
+	for _, r := range integer + fraction {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+	}
+
+	roundUp := false
+	if len(fraction) > moneyDecimals {
+		roundUp = fraction[moneyDecimals] >= '5'
+		fraction = fraction[:moneyDecimals]
+	}
+	fraction += strings.Repeat("0", moneyDecimals-len(fraction))
+
+	cents, err := strconv.ParseInt(integer+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", s, err)
+	}
+	if roundUp {
+		cents++
+	}
+	if negative {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+// Return the decimal separator of a number, a comma if it is after the last dot, e.g. 1.234,56,
+// or if it is the only one and followed by 1 or 2 digits, e.g. 12,50, and a dot otherwise
+// A comma followed by 3 digits separates thousands, e.g. 1,234
+func decimalSeparator(number string) string {
+	dot, comma := strings.LastIndex(number, "."), strings.LastIndex(number, ",")
+	if comma < 0 || dot > comma {
+		return "."
+	} else if dot >= 0 {
+		return ","
+	}
+	if digits := len(number) - comma - 1; strings.Count(number, ",") == 1 && digits > 0 && digits < 3 {
+		return ","
+	}
+	return "."
+}
+
+// Remove the thousands separators of a number and use a decimal dot, e.g. 1.234,56 becomes 1234.56
+func plainNumber(number string) string {
+	if decimalSeparator(number) == "," {
+		return strings.Replace(strings.ReplaceAll(number, ".", ""), ",", ".", 1)
+	}
+	return strings.ReplaceAll(number, ",", "")
+}
+
+// Convert a float to Money, rounding to the nearest cent
+func MoneyFromFloat(f float64) Money {
+	return Money(math.Round(f * moneyScale))
+}
+
+func (m Money) Float64() float64 {
+	return float64(m) / moneyScale
+}
+
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// Multiply by a rate, e.g. an exchange rate, rounding to the nearest cent
+func (m Money) MulRate(rate float64) Money {
+	return MoneyFromFloat(m.Float64() * rate)
+}
+
+// Return -1, 0 or 1 if m is less than, equal to or greater than other
+func (m Money) Cmp(other Money) int {
+	switch {
+	case m < other:
+		return -1
+	case m > other:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Plain decimal representation, e.g. -1234.56
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+	}
+	abs := int64(m.Abs())
+	return fmt.Sprintf("%s%d.%0*d", sign, abs/moneyScale, moneyDecimals, abs%moneyScale)
+}
+
+// Decimal representation with thousands separators, e.g. -1,234.56
+func (m Money) Format() string {
+	integer, decimal, _ := strings.Cut(m.String(), ".")
 	return formatInteger(integer) + "." + decimal
 }
 
-func FormatMoneyInteger(amount float64) string {
-	return formatInteger(fmt.Sprintf("%.0f", amount))
+// Rounded to an integer with thousands separators, e.g. -1,235
+func (m Money) FormatInteger() string {
+	cents := int64(m.Abs())
+	units := (cents + moneyScale/2) / moneyScale
+	if m < 0 && units != 0 {
+		return formatInteger(fmt.Sprintf("-%d", units))
+	}
+	return formatInteger(fmt.Sprintf("%d", units))
+}
+
+// Money is written in JSON as a number, e.g. 1234.56
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// Money can be written in JSON as a number or a string, e.g. 1234.56 or "1,234.56"
+func (m *Money) UnmarshalJSON(b []byte) error {
+	amount, err := ParseMoney(string(bytes.Trim(b, `"`)))
+	if err != nil {
+		return err
+	}
+	*m = amount
+	return nil
 }
 
 // Insert commas into integer on every 3 digits
-// 1234 => 1,234; 1234567 => 1,234,567
+// 1234 => 1,234; 1234567 => 1,234,567; -123 => -123
 func formatInteger(integer string) string {
+	if unsigned, negative := strings.CutPrefix(integer, "-"); negative {
+		return "-" + formatInteger(unsigned)
+	}
 	formattedInteger := ""
 	for i, r := range integer {
 		if i > 0 && (len(integer)-i)%3 == 0 {
@@ -0,0 +1,88 @@
+package data
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Money
+		wantErr bool
+	}{
+		{s: "0", want: 0},
+		{s: "12", want: 1200},
+		{s: "12.5", want: 1250},
+		{s: "12.50", want: 1250},
+		{s: "-12.50", want: -1250},
+		{s: "+12.50", want: 1250},
+		{s: ".5", want: 50},
+		{s: "1,234,567.89", want: 123456789},
+		{s: " 42 ", want: 4200},
+		{s: "0.005", want: 1},
+		{s: "0.004", want: 0},
+		{s: "-0.005", want: -1},
+		{s: "12,50", want: 1250},
+		{s: "12,5", want: 1250},
+		{s: "-12,50", want: -1250},
+		{s: "1.234,56", want: 123456},
+		{s: "1.234.567,89", want: 123456789},
+		{s: "1,234", want: 123400},
+		{s: "1,234,567", want: 123456700},
+		{s: "", wantErr: true},
+		{s: "-", wantErr: true},
+		{s: "abc", wantErr: true},
+		{s: "12.3.4", wantErr: true},
+		{s: "$12", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMoney(%q) error = %v, want error %v", tt.s, err, tt.wantErr)
+		} else if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	tests := []struct {
+		m                      Money
+		str, format, formatInt string
+	}{
+		{0, "0.00", "0.00", "0"},
+		{5, "0.05", "0.05", "0"},
+		{-5, "-0.05", "-0.05", "0"},
+		{123456, "1234.56", "1,234.56", "1,235"},
+		{-123449, "-1234.49", "-1,234.49", "-1,234"},
+		{100000000, "1000000.00", "1,000,000.00", "1,000,000"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.str {
+			t.Errorf("Money(%d).String() = %q, want %q", tt.m, got, tt.str)
+		}
+		if got := tt.m.Format(); got != tt.format {
+			t.Errorf("Money(%d).Format() = %q, want %q", tt.m, got, tt.format)
+		}
+		if got := tt.m.FormatInteger(); got != tt.formatInt {
+			t.Errorf("Money(%d).FormatInteger() = %q, want %q", tt.m, got, tt.formatInt)
+		}
+	}
+}
+
+func TestMoneyMulRate(t *testing.T) {
+	tests := []struct {
+		m    Money
+		rate float64
+		want Money
+	}{
+		{1000, 1.1, 1100},
+		{1, 0.5, 1},
+		{-1, 0.5, -1},
+		{12345, 0, 0},
+		{10000, 1.08695, 10870},
+	}
+	for _, tt := range tests {
+		if got := tt.m.MulRate(tt.rate); got != tt.want {
+			t.Errorf("Money(%d).MulRate(%v) = %d, want %d", tt.m, tt.rate, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package ofx
+
+import (
+	"cashd/internal/data"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/pflag"
+)
+
+type OfxDataSource struct {
+	balances []data.Balance
+	problems []*data.ParseError
+}
+
+var ofxFiles []string
+
+func init() {
+	pflag.StringSliceVar(&ofxFiles, "ofx", []string{}, "OFX or QFX file path (can be specified multiple times)")
+}
+
+// Transactions, balances and skipped records read from one OFX file
+type ofxResult struct {
+	txns     []*data.Transaction
+	balances []data.Balance
+	problems []*data.ParseError
+	err      error
+}
+
+func (s *OfxDataSource) Name() string {
+	return "ofx"
+}
+
+func (s *OfxDataSource) LoadTransactions() ([]*data.Transaction, error) {
+	allTxns := []*data.Transaction{}
+	s.balances = []data.Balance{}
+	s.problems = []*data.ParseError{}
+
+	resolvedFilePaths := []string{}
+	for _, pattern := range ofxFiles {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve glob pattern %s: %w", pattern, err)
+		}
+		resolvedFilePaths = append(resolvedFilePaths, matches...)
+	}
+	if len(resolvedFilePaths) == 0 {
+		return []*data.Transaction{}, nil
+	}
+
+	policy, err := data.GetParseErrorPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	// Buffered so that no reader is left blocked when returning early on error
+	resultChan := make(chan ofxResult, len(resolvedFilePaths))
+	for _, filePath := range resolvedFilePaths {
+		go func(fp string) {
+			resultChan <- readOfx(fp, policy)
+		}(filePath)
+	}
+
+	for i := 0; i < len(resolvedFilePaths); i++ {
+		result := <-resultChan
+		if result.err != nil {
+			return nil, result.err
+		}
+		allTxns = append(allTxns, result.txns...)
+		s.balances = append(s.balances, result.balances...)
+		s.problems = append(s.problems, result.problems...)
+	}
+	sort.SliceStable(allTxns, func(i, j int) bool {
+		return allTxns[i].Date.Before(allTxns[j].Date)
+	})
+	sort.SliceStable(s.problems, func(i, j int) bool {
+		a, b := s.problems[i], s.problems[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return allTxns, nil
+}
+
+func (s *OfxDataSource) Balances() []data.Balance {
+	return s.balances
+}
+
+func (s *OfxDataSource) Problems() []*data.ParseError {
+	return s.problems
+}
+
+func (s *OfxDataSource) WatchedFiles() []string {
+	return ofxFiles
+}
+
+// Read transactions and ledger balances of all statements in an OFX file
+// Records that fail to parse are handled following the policy
+func readOfx(filePath string, policy data.ParseErrorPolicy) ofxResult {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return ofxResult{err: fmt.Errorf("failed to read OFX file %s: %w", filePath, err)}
+	}
+	root, err := parseElements(string(content))
+	if err != nil {
+		return ofxResult{err: fmt.Errorf("failed to parse OFX file %s: %w", filePath, err)}
+	}
+	statements, err := findStatements(root)
+	if err != nil {
+		return ofxResult{err: fmt.Errorf("failed to parse OFX file %s: %w", filePath, err)}
+	}
+
+	result := ofxResult{
+		txns:     []*data.Transaction{},
+		balances: []data.Balance{},
+		problems: []*data.ParseError{},
+	}
+	handle := func(parseErr *data.ParseError) error {
+		parseErr.File = filePath
+		return policy.Handle(parseErr, &result.problems)
+	}
+	for _, s := range statements {
+		for _, el := range s.txns {
+			txn, parseErr := parseTransaction(el, s)
+			if parseErr != nil {
+				if err := handle(parseErr); err != nil {
+					return ofxResult{err: err}
+				}
+				continue
+			}
+			txn.File = filePath
+			result.txns = append(result.txns, txn)
+		}
+
+		balance, parseErr := parseBalance(s)
+		if parseErr != nil {
+			if err := handle(parseErr); err != nil {
+				return ofxResult{err: err}
+			}
+		} else if balance != nil {
+			result.balances = append(result.balances, *balance)
+		}
+	}
+	return result
+}
+
+func (s *OfxDataSource) Preferred() bool {
+	return s.Enabled()
+}
+
+func (s *OfxDataSource) Enabled() bool {
+	return len(ofxFiles) > 0
+}
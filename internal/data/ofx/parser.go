@@ -0,0 +1,278 @@
+package ofx
+
+import (
+	"cashd/internal/data"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// element is an OFX aggregate with child elements, or a leaf with a value
+type element struct {
+	name     string
+	value    string
+	children []*element
+	// Line number of the opening tag, starting from 1
+	line int
+}
+
+// Return the first descendant with the name, nil if there is none
+func (e *element) find(name string) *element {
+	for _, c := range e.children {
+		if c.name == name {
+			return c
+		}
+		if found := c.find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Return all descendants with the name, without looking inside the matching ones
+func (e *element) findAll(name string) []*element {
+	found := []*element{}
+	for _, c := range e.children {
+		if c.name == name {
+			found = append(found, c)
+		} else {
+			found = append(found, c.findAll(name)...)
+		}
+	}
+	return found
+}
+
+// Return the value of the first descendant with the name, empty if there is none
+func (e *element) get(name string) string {
+	if found := e.find(name); found != nil {
+		return found.value
+	}
+	return ""
+}
+
+// Parse the elements of an OFX file, both SGML (OFX 1.x) and XML (OFX 2.x)
+// SGML leaves have no closing tags, so any element followed by a value is a leaf and its closing tag, if any, is ignored
+func parseElements(content string) (*element, error) {
+	start := strings.Index(strings.ToUpper(content), "<OFX>")
+	if start < 0 {
+		return nil, fmt.Errorf("no <OFX> element found")
+	}
+	line := strings.Count(content[:start], "\n") + 1
+
+	root := &element{}
+	stack := []*element{root}
+	pos := start
+	for {
+		next := strings.IndexByte(content[pos:], '<')
+		if next < 0 {
+			break
+		}
+		tagStart := pos + next
+		line += strings.Count(content[pos:tagStart], "\n")
+		tagLen := strings.IndexByte(content[tagStart:], '>')
+		if tagLen < 0 {
+			return nil, fmt.Errorf("line %d: unclosed tag", line)
+		}
+		tag := strings.TrimSpace(content[tagStart+1 : tagStart+tagLen])
+		pos = tagStart + tagLen + 1
+
+		if strings.HasPrefix(tag, "?") || strings.HasPrefix(tag, "!") {
+			// Processing instructions and comments
+			continue
+		}
+		if name, ok := strings.CutPrefix(tag, "/"); ok {
+			// Close the aggregate and any SGML elements left open inside it
+			name = strings.ToUpper(strings.TrimSpace(name))
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].name == name {
+					stack = stack[:i]
+					break
+				}
+			}
+			continue
+		}
+
+		name, selfClosing := strings.CutSuffix(tag, "/")
+		el := &element{name: strings.ToUpper(strings.TrimSpace(name)), line: line}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, el)
+		if selfClosing {
+			continue
+		}
+
+		valueEnd := len(content)
+		if next := strings.IndexByte(content[pos:], '<'); next >= 0 {
+			valueEnd = pos + next
+		}
+		if value := strings.TrimSpace(content[pos:valueEnd]); value != "" {
+			el.value = html.UnescapeString(value)
+		} else {
+			stack = append(stack, el)
+		}
+	}
+	return root, nil
+}
+
+// A bank or credit card statement
+type statement struct {
+	account     string
+	accountType data.AccountType
+	currency    string
+	txns        []*element
+	balance     *element
+}
+
+// Find bank and credit card statements, investment statements are not supported
+func findStatements(root *element) ([]statement, error) {
+	// Name accounts after the financial institution if the file has it
+	org := root.get("ORG")
+	statements := []statement{}
+	for _, name := range []string{"STMTRS", "CCSTMTRS"} {
+		for _, rs := range root.findAll(name) {
+			s := statement{
+				currency: data.CurrencyOfCode(rs.get("CURDEF")),
+				txns:     rs.findAll("STMTTRN"),
+				balance:  rs.find("LEDGERBAL"),
+			}
+			if acct := rs.find("BANKACCTFROM"); acct != nil {
+				s.account = acct.get("ACCTID")
+				s.accountType = bankAccountTypes[strings.ToUpper(acct.get("ACCTTYPE"))]
+				if s.accountType == "" {
+					s.accountType = data.AcctBankAccount
+				}
+			} else if acct := rs.find("CCACCTFROM"); acct != nil {
+				s.account = acct.get("ACCTID")
+				s.accountType = data.AcctCreditCard
+			}
+			if s.account == "" {
+				return nil, fmt.Errorf("line %d: statement has no account", rs.line)
+			}
+			if org != "" {
+				s.account = org + " " + s.account
+			}
+			statements = append(statements, s)
+		}
+	}
+	return statements, nil
+}
+
+var bankAccountTypes = map[string]data.AccountType{
+	"CHECKING":   data.AcctBankAccount,
+	"SAVINGS":    data.AcctBankAccount,
+	"MONEYMRKT":  data.AcctBankAccount,
+	"CREDITLINE": data.AcctCreditCard,
+}
+
+// Categories implied by transaction types, other types are uncategorized
+var trnTypeCategories = map[string]string{
+	"INT":       "Interest",
+	"DIV":       "Dividend",
+	"FEE":       "Fees",
+	"SRVCHG":    "Fees",
+	"ATM":       "Cash Withdrawal",
+	"CASH":      "Cash Withdrawal",
+	"DIRECTDEP": "Direct Deposit",
+}
+
+// Parse a STMTTRN record, the amount sign decides whether it is income or expense
+func parseTransaction(el *element, s statement) (*data.Transaction, *data.ParseError) {
+	fieldError := func(column, value, format string, a ...any) *data.ParseError {
+		return &data.ParseError{Line: el.line, Column: column, Value: value, Err: fmt.Errorf(format, a...)}
+	}
+
+	posted := el.get("DTPOSTED")
+	date, err := parseDate(posted)
+	if err != nil {
+		return nil, fieldError("DTPOSTED", posted, "%v", err)
+	}
+
+	trnAmt := el.get("TRNAMT")
+	amount, err := parseAmount(trnAmt)
+	if err != nil {
+		return nil, fieldError("TRNAMT", trnAmt, "not an amount")
+	}
+	var txnType data.TransactionType
+	switch {
+	case amount > 0:
+		txnType = data.Income
+	case amount < 0:
+		txnType = data.Expense
+	default:
+		return nil, fieldError("TRNAMT", trnAmt, "zero amount has no transaction type")
+	}
+
+	category, ok := trnTypeCategories[strings.ToUpper(el.get("TRNTYPE"))]
+	if !ok {
+		category = data.Uncategorized
+	}
+	description := el.get("NAME")
+	if description == "" {
+		description = el.get("MEMO")
+	}
+	if description == "" {
+		description = el.get("TRNTYPE")
+	}
+
+	txn := &data.Transaction{
+		Date:        date,
+		Type:        txnType,
+		AccountType: s.accountType,
+		Account:     s.account,
+		Category:    category,
+		Amount:      amount.Abs(),
+		Description: description,
+		Currency:    s.currency,
+		Line:        el.line,
+	}
+	if err := txn.Validate(); err != nil {
+		return nil, &data.ParseError{Line: el.line, Err: fmt.Errorf("transaction is incomplete: %w", err)}
+	}
+	return txn, nil
+}
+
+// Parse the ledger balance of the statement, nil if it has none
+// The balance applies at the end of its date, statements include all transactions up to then
+func parseBalance(s statement) (*data.Balance, *data.ParseError) {
+	if s.balance == nil {
+		return nil, nil
+	}
+	balAmt := s.balance.get("BALAMT")
+	amount, err := parseAmount(balAmt)
+	if err != nil {
+		return nil, &data.ParseError{Line: s.balance.line, Column: "BALAMT", Value: balAmt, Err: fmt.Errorf("not an amount")}
+	}
+	asOf := s.balance.get("DTASOF")
+	date, err := parseDate(asOf)
+	if err != nil {
+		return nil, &data.ParseError{Line: s.balance.line, Column: "DTASOF", Value: asOf, Err: err}
+	}
+	return &data.Balance{
+		Date:        date,
+		AccountType: s.accountType,
+		Account:     s.account,
+		Amount:      amount,
+		Currency:    s.currency,
+	}, nil
+}
+
+// Parse an OFX amount, which has no thousands separators and may use a comma as the decimal separator
+func parseAmount(s string) (data.Money, error) {
+	if strings.Contains(s, ",") && !strings.Contains(s, ".") {
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	return data.ParseMoney(s)
+}
+
+// Parse the date of an OFX date time, e.g. 20240131, 20240131120000 or 20240131120000.000[-5:EST]
+// The time and time zone are ignored, as transactions only have dates
+func parseDate(s string) (time.Time, error) {
+	if len(s) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date")
+	}
+	date, err := time.Parse("20060102", s[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date")
+	}
+	return date, nil
+}
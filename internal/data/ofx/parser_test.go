@@ -0,0 +1,137 @@
+package ofx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseElements(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		// Line of the second transaction
+		line int
+	}{
+		{
+			name: "sgml",
+			content: `OFXHEADER:100
+DATA:OFXSGML
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>EUR
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240105120000[-5:EST]
+<TRNAMT>-12.50
+<NAME>Caf&eacute; &amp; Bar
+<MEMO>
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<MEMO>
+<DTPOSTED>20240106
+<TRNAMT>100
+<NAME>Refund
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`,
+			line: 15,
+		},
+		{
+			name: "xml",
+			content: `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX>
+  <BANKMSGSRSV1><STMTTRNRS><STMTRS>
+    <CURDEF>EUR</CURDEF>
+    <BANKTRANLIST>
+      <STMTTRN>
+        <TRNTYPE>DEBIT</TRNTYPE>
+        <DTPOSTED>20240105120000[-5:EST]</DTPOSTED>
+        <TRNAMT>-12.50</TRNAMT>
+        <NAME>Caf&eacute; &amp; Bar</NAME>
+        <MEMO/>
+      </STMTTRN>
+      <STMTTRN>
+        <TRNTYPE>CREDIT</TRNTYPE>
+        <MEMO></MEMO>
+        <DTPOSTED>20240106</DTPOSTED>
+        <TRNAMT>100</TRNAMT>
+        <NAME>Refund</NAME>
+      </STMTTRN>
+    </BANKTRANLIST>
+  </STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`,
+			line: 14,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parseElements(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := root.get("CURDEF"); got != "EUR" {
+				t.Errorf("CURDEF = %q, want EUR", got)
+			}
+			txns := root.findAll("STMTTRN")
+			if len(txns) != 2 {
+				t.Fatalf("got %d STMTTRN elements, want 2", len(txns))
+			}
+			want := [][4]string{
+				{"DEBIT", "20240105120000[-5:EST]", "-12.50", "Café & Bar"},
+				{"CREDIT", "20240106", "100", "Refund"},
+			}
+			for i, txn := range txns {
+				got := [4]string{txn.get("TRNTYPE"), txn.get("DTPOSTED"), txn.get("TRNAMT"), txn.get("NAME")}
+				if got != want[i] {
+					t.Errorf("transaction %d = %q, want %q", i, got, want[i])
+				}
+				if memo := txn.find("MEMO"); memo == nil || memo.value != "" {
+					t.Errorf("transaction %d has memo %v, want an empty one", i, memo)
+				}
+			}
+			if txns[1].line != tt.line {
+				t.Errorf("second transaction at line %d, want %d", txns[1].line, tt.line)
+			}
+		})
+	}
+}
+
+func TestParseElementsErrors(t *testing.T) {
+	for _, content := range []string{"OFXHEADER:100\n<BANKMSGSRSV1>", "<OFX><STMTTRN"} {
+		if _, err := parseElements(content); err == nil {
+			t.Errorf("parseElements(%q) succeeded, want an error", content)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    time.Time
+		wantErr bool
+	}{
+		{s: "20240131", want: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{s: "20240131120000", want: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		// The date is kept as written, whatever the time zone
+		{s: "20240131230000.000[-5:EST]", want: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{s: "20240131000000[+9:JST]", want: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{s: "2024013", wantErr: true},
+		{s: "20241301", wantErr: true},
+		{s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDate(%q) error = %v, want error %v", tt.s, err, tt.wantErr)
+		} else if !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %s, want %s", tt.s, got, tt.want)
+		}
+	}
+}
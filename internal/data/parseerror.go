@@ -0,0 +1,78 @@
+package data
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// ParseError is a problem with one record of an imported file
+type ParseError struct {
+	File string
+	// Line number in the file, starting from 1
+	Line int
+	// Column header of the offending value, empty if the problem is not with a single value
+	Column string
+	Value  string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s:%d: column %q, value %q: %v", e.File, e.Line, e.Column, e.Value, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ProblemSource is implemented by data sources that can skip records they fail to parse
+type ProblemSource interface {
+	// Records skipped while loading transactions, only collected with the collect parse error policy
+	Problems() []*ParseError
+}
+
+// ParseErrorPolicy decides what happens to records that fail to parse
+type ParseErrorPolicy string
+
+const (
+	// Stop loading and report the error
+	FailOnParseError ParseErrorPolicy = "fail"
+	// Log the error and skip the record
+	SkipOnParseError ParseErrorPolicy = "skip"
+	// Skip the record and collect the error for review in the TUI
+	CollectOnParseError ParseErrorPolicy = "collect"
+)
+
+var parseErrorPolicyFlag string
+
+func init() {
+	pflag.StringVar(&parseErrorPolicyFlag, "on-parse-error", string(CollectOnParseError), "What to do with records that fail to parse: fail, skip or collect")
+}
+
+// Return the parse error policy set by --on-parse-error
+func GetParseErrorPolicy() (ParseErrorPolicy, error) {
+	switch p := ParseErrorPolicy(strings.ToLower(strings.TrimSpace(parseErrorPolicyFlag))); p {
+	case FailOnParseError, SkipOnParseError, CollectOnParseError:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid parse error policy %q, expecting fail, skip or collect", parseErrorPolicyFlag)
+	}
+}
+
+// Handle a parse error following the policy
+// Return the error if loading should stop, otherwise the error is logged and appended to problems when collecting
+func (p ParseErrorPolicy) Handle(err *ParseError, problems *[]*ParseError) error {
+	switch p {
+	case FailOnParseError:
+		return err
+	case CollectOnParseError:
+		*problems = append(*problems, err)
+	}
+	log.Printf("Skipped record: %v", err)
+	return nil
+}
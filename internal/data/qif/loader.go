@@ -0,0 +1,182 @@
+package qif
+
+import (
+	"cashd/internal/data"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+type QifDataSource struct {
+	balances []data.Balance
+	problems []*data.ParseError
+}
+
+var qifFiles []string
+
+func init() {
+	pflag.StringSliceVar(&qifFiles, "qif", []string{}, "QIF file path (can be specified multiple times)")
+}
+
+// Content read from one QIF file
+type qifResult struct {
+	file     *qifFile
+	problems []*data.ParseError
+	err      error
+}
+
+func (s *QifDataSource) Name() string {
+	return "qif"
+}
+
+func (s *QifDataSource) LoadTransactions() ([]*data.Transaction, error) {
+	s.balances = []data.Balance{}
+	s.problems = []*data.ParseError{}
+
+	resolvedFilePaths := []string{}
+	for _, pattern := range qifFiles {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve glob pattern %s: %w", pattern, err)
+		}
+		resolvedFilePaths = append(resolvedFilePaths, matches...)
+	}
+	if len(resolvedFilePaths) == 0 {
+		return []*data.Transaction{}, nil
+	}
+
+	policy, err := data.GetParseErrorPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	// Buffered so that no reader is left blocked when returning early on error
+	resultChan := make(chan qifResult, len(resolvedFilePaths))
+	for _, filePath := range resolvedFilePaths {
+		go func(fp string) {
+			resultChan <- readQif(fp, policy)
+		}(filePath)
+	}
+
+	entries := []entry{}
+	accountTypes := map[string]data.AccountType{}
+	for i := 0; i < len(resolvedFilePaths); i++ {
+		result := <-resultChan
+		if result.err != nil {
+			return nil, result.err
+		}
+		entries = append(entries, result.file.entries...)
+		s.balances = append(s.balances, result.file.balances...)
+		for account, accountType := range result.file.accountTypes {
+			accountTypes[account] = accountType
+		}
+		s.problems = append(s.problems, result.problems...)
+	}
+
+	allTxns := mergeTransfers(entries)
+	for _, t := range allTxns {
+		// Accounts only seen as the other side of transfers are assumed to be bank accounts
+		if t.AccountType == "" {
+			t.AccountType = accountTypeOrDefault(accountTypes, t.Account)
+		}
+		if t.IsTransfer() && t.ToAccountType == "" {
+			t.ToAccountType = accountTypeOrDefault(accountTypes, t.ToAccount)
+		}
+	}
+	sort.SliceStable(allTxns, func(i, j int) bool {
+		return allTxns[i].Date.Before(allTxns[j].Date)
+	})
+	sort.SliceStable(s.problems, func(i, j int) bool {
+		a, b := s.problems[i], s.problems[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return allTxns, nil
+}
+
+func accountTypeOrDefault(accountTypes map[string]data.AccountType, account string) data.AccountType {
+	if accountType, ok := accountTypes[account]; ok {
+		return accountType
+	}
+	return data.AcctBankAccount
+}
+
+// Transfers between two exported accounts appear in both of them, keep only one side of each
+// When only one of the accounts is exported, whichever side it has is kept
+func mergeTransfers(entries []entry) []*data.Transaction {
+	type transferKey struct {
+		date      time.Time
+		account   string
+		toAccount string
+		amount    data.Money
+	}
+	sides := map[transferKey][2]int{}
+	for _, e := range entries {
+		if !e.txn.IsTransfer() {
+			continue
+		}
+		key := transferKey{e.txn.Date, e.txn.Account, e.txn.ToAccount, e.txn.Amount}
+		count := sides[key]
+		if e.inflow {
+			count[1]++
+		} else {
+			count[0]++
+		}
+		sides[key] = count
+	}
+
+	txns := []*data.Transaction{}
+	for _, e := range entries {
+		if e.txn.IsTransfer() {
+			count := sides[transferKey{e.txn.Date, e.txn.Account, e.txn.ToAccount, e.txn.Amount}]
+			// Keep the sending side unless the receiving side has more of the same transfer
+			if keepInflow := count[1] > count[0]; e.inflow != keepInflow {
+				continue
+			}
+		}
+		txns = append(txns, e.txn)
+	}
+	return txns
+}
+
+func (s *QifDataSource) Balances() []data.Balance {
+	return s.balances
+}
+
+func (s *QifDataSource) Problems() []*data.ParseError {
+	return s.problems
+}
+
+func (s *QifDataSource) WatchedFiles() []string {
+	return qifFiles
+}
+
+// Read a QIF file, records that fail to parse are handled following the policy
+func readQif(filePath string, policy data.ParseErrorPolicy) qifResult {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return qifResult{err: fmt.Errorf("failed to read QIF file %s: %w", filePath, err)}
+	}
+	problems := []*data.ParseError{}
+	f, err := parseQif(string(content), filePath, func(parseErr *data.ParseError) error {
+		return policy.Handle(parseErr, &problems)
+	})
+	if err != nil {
+		return qifResult{err: err}
+	}
+	return qifResult{file: f, problems: problems}
+}
+
+func (s *QifDataSource) Preferred() bool {
+	return s.Enabled()
+}
+
+func (s *QifDataSource) Enabled() bool {
+	return len(qifFiles) > 0
+}
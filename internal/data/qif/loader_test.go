@@ -0,0 +1,31 @@
+package qif
+
+import (
+	"testing"
+	"time"
+
+	"cashd/internal/data"
+)
+
+func TestMergeTransfers(t *testing.T) {
+	day := time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+	transfer := func(account, toAccount string) *data.Transaction {
+		return &data.Transaction{Date: day, Type: data.Transfer, Account: account, ToAccount: toAccount, Amount: 20000}
+	}
+	// The same transfer exported from both accounts, and one exported only from the receiving account
+	sent := transfer("Savings", "Checking")
+	received := transfer("Savings", "Checking")
+	onlyReceived := transfer("Savings", "Brokerage")
+	expense := &data.Transaction{Date: day, Type: data.Expense, Account: "Checking", Amount: 500}
+
+	got := mergeTransfers([]entry{{received, true}, {expense, false}, {sent, false}, {onlyReceived, true}})
+	want := []*data.Transaction{expense, sent, onlyReceived}
+	if len(got) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("transaction %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package qif
+
+import (
+	"cashd/internal/data"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Account types of the QIF sections with bank transactions, other sections are skipped
+var sectionAccountTypes = map[string]data.AccountType{
+	"bank":  data.AcctBankAccount,
+	"ccard": data.AcctCreditCard,
+	"cash":  data.AcctCash,
+}
+
+// A transaction and whether it is the receiving side of a transfer,
+// which is also exported as the sending side when the other account is in the file
+type entry struct {
+	txn    *data.Transaction
+	inflow bool
+}
+
+// Everything read from a QIF file
+type qifFile struct {
+	entries []entry
+	// Opening balances set by Quicken's "Opening Balance" records
+	balances []data.Balance
+	// Type of each account with a section in the file
+	accountTypes map[string]data.AccountType
+}
+
+// A record being parsed, fields are added line by line until the ^ terminator
+type record struct {
+	line     int
+	date     string
+	amount   string
+	payee    string
+	memo     string
+	category string
+	splits   []splitRecord
+}
+
+type splitRecord struct {
+	category string
+	amount   string
+}
+
+// Parse a QIF file, records that fail to parse are passed to handle, which stops parsing by returning an error
+// The file name is the account name of sections without a preceding !Account
+func parseQif(content, filePath string, handle func(*data.ParseError) error) (*qifFile, error) {
+	f := &qifFile{
+		entries:      []entry{},
+		balances:     []data.Balance{},
+		accountTypes: map[string]data.AccountType{},
+	}
+	defaultAccount := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+
+	var account, listedAccount string
+	var accountType data.AccountType
+	// Either in an !Account block, a supported !Type section, or skipping an unsupported section
+	inAccountBlock, inSection := false, false
+	rec := &record{}
+
+	for i, line := range strings.Split(content, "\n") {
+		lineNum := i + 1
+		line = strings.TrimRight(line, "\r \t")
+		if line == "" {
+			continue
+		}
+
+		if line[0] == '!' {
+			header := strings.ToLower(strings.TrimSpace(line[1:]))
+			inAccountBlock, inSection = false, false
+			rec = &record{}
+			if header == "account" {
+				inAccountBlock = true
+				listedAccount = ""
+			} else if sectionType, ok := strings.CutPrefix(header, "type:"); ok {
+				accountType, inSection = sectionAccountTypes[strings.TrimSpace(sectionType)]
+				account = listedAccount
+				if account == "" {
+					account = defaultAccount
+				}
+				if inSection {
+					f.accountTypes[account] = accountType
+				}
+			}
+			// Options, e.g. !Option:AutoSwitch, and unsupported sections are skipped
+			continue
+		}
+
+		code, value := line[0], strings.TrimSpace(line[1:])
+		if inAccountBlock {
+			if code == 'N' {
+				listedAccount = value
+			}
+			continue
+		}
+		if !inSection {
+			continue
+		}
+
+		if rec.line == 0 {
+			rec.line = lineNum
+		}
+		switch code {
+		case 'D':
+			rec.date = value
+		case 'T', 'U':
+			rec.amount = value
+		case 'P':
+			rec.payee = value
+		case 'M':
+			rec.memo = value
+		case 'L':
+			rec.category = value
+		case 'S':
+			rec.splits = append(rec.splits, splitRecord{category: value})
+		case '$':
+			if n := len(rec.splits); n > 0 {
+				rec.splits[n-1].amount = value
+			}
+		case '^':
+			if err := f.addRecord(rec, account, accountType); err != nil {
+				err.File = filePath
+				if err := handle(err); err != nil {
+					return nil, err
+				}
+			}
+			rec = &record{}
+		}
+		// Other fields, e.g. check numbers and cleared status, are ignored
+	}
+	for _, e := range f.entries {
+		e.txn.File = filePath
+	}
+	return f, nil
+}
+
+// Add the transaction or opening balance of a record
+func (f *qifFile) addRecord(rec *record, account string, accountType data.AccountType) *data.ParseError {
+	fieldError := func(column, value, format string, a ...any) *data.ParseError {
+		return &data.ParseError{Line: rec.line, Column: column, Value: value, Err: fmt.Errorf(format, a...)}
+	}
+
+	date, err := parseDate(rec.date)
+	if err != nil {
+		return fieldError("D", rec.date, "%v", err)
+	}
+	amount, err := data.ParseMoney(rec.amount)
+	if err != nil {
+		return fieldError("T", rec.amount, "not an amount")
+	}
+
+	description := rec.payee
+	if description == "" {
+		description = rec.memo
+	}
+	txn := &data.Transaction{
+		Date:        date,
+		AccountType: accountType,
+		Account:     account,
+		Amount:      amount.Abs(),
+		Description: description,
+		Currency:    data.DefaultCurrency,
+		Line:        rec.line,
+	}
+
+	inflow := false
+	if toAccount, ok := transferAccount(rec.category); ok && len(rec.splits) == 0 {
+		if toAccount == account {
+			// Quicken starts each account with a transfer to itself for the opening balance
+			f.balances = append(f.balances, data.Balance{
+				Date:        date.AddDate(0, 0, -1),
+				AccountType: accountType,
+				Account:     account,
+				Amount:      amount,
+				Currency:    data.DefaultCurrency,
+			})
+			return nil
+		}
+		// The type of the other account is only known once all files are read
+		txn.Type = data.Transfer
+		txn.ToAccount = toAccount
+		if amount > 0 {
+			// Money comes from the other account
+			inflow = true
+			txn.Account, txn.AccountType = toAccount, ""
+			txn.ToAccount, txn.ToAccountType = account, accountType
+		}
+		if txn.Description == "" {
+			txn.Description = fmt.Sprintf("Transfer to %s", txn.ToAccount)
+		}
+	} else {
+		if amount >= 0 {
+			txn.Type = data.Income
+		} else {
+			txn.Type = data.Expense
+		}
+		txn.Category = rec.category
+		if parseErr := addSplits(txn, rec); parseErr != nil {
+			return parseErr
+		}
+		if txn.Category == "" {
+			txn.Category = data.Uncategorized
+		}
+	}
+
+	if txn.Amount == 0 {
+		return fieldError("T", rec.amount, "zero amount")
+	}
+	if txn.Description == "" {
+		txn.Description = txn.Category
+	}
+	f.entries = append(f.entries, entry{txn, inflow})
+	return nil
+}
+
+// Set the splits of a transaction with more than one category, like ledger transactions with several postings
+// Transfers in splits are not supported and left out
+func addSplits(txn *data.Transaction, rec *record) *data.ParseError {
+	categories := []string{}
+	for _, s := range rec.splits {
+		amount, err := data.ParseMoney(s.amount)
+		if err != nil {
+			return &data.ParseError{Line: rec.line, Column: "$", Value: s.amount, Err: fmt.Errorf("not an amount")}
+		}
+		if toAccount, ok := transferAccount(s.category); ok {
+			log.Printf("QIF record at line %d: ignoring split transfer to %s", rec.line, toAccount)
+			continue
+		}
+		category := s.category
+		if category == "" {
+			category = data.Uncategorized
+		}
+		split := data.Split{Type: data.Expense, Category: category, Amount: amount.Abs()}
+		if amount > 0 {
+			split.Type = data.Income
+		}
+		txn.Splits = append(txn.Splits, split)
+		if !slices.Contains(categories, category) {
+			categories = append(categories, category)
+		}
+	}
+	if len(categories) > 0 {
+		txn.Category = strings.Join(categories, ", ")
+	}
+	if len(txn.Splits) == 1 {
+		// A single split is the transaction itself
+		txn.Splits = nil
+	}
+	return nil
+}
+
+// Return the account of a transfer category, e.g. [Savings]
+func transferAccount(category string) (string, bool) {
+	if strings.HasPrefix(category, "[") && strings.HasSuffix(category, "]") {
+		return strings.TrimSpace(category[1 : len(category)-1]), true
+	}
+	return "", false
+}
+
+// Parse a QIF date, e.g. 1/ 2'24, 01/02/2024, 1/2/98 or 2024-01-02
+// Dates are month first, two digit years after an apostrophe are in the 2000s as Quicken writes them
+func parseDate(s string) (time.Time, error) {
+	normalized := strings.ReplaceAll(s, " ", "")
+	apostrophe := strings.Contains(normalized, "'")
+	normalized = strings.NewReplacer("'", "/", "-", "/", ".", "/").Replace(normalized)
+	parts := strings.Split(normalized, "/")
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("invalid date")
+	}
+	if len(parts[0]) == 4 {
+		// Year first
+		parts = []string{parts[1], parts[2], parts[0]}
+	}
+
+	nums := [3]int{}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date")
+		}
+		nums[i] = n
+	}
+	month, day, year := nums[0], nums[1], nums[2]
+	if len(parts[2]) <= 2 {
+		if apostrophe || year < 50 {
+			year += 2000
+		} else {
+			year += 1900
+		}
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Month() != time.Month(month) || date.Day() != day {
+		return time.Time{}, fmt.Errorf("invalid date")
+	}
+	return date, nil
+}
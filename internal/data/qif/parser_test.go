@@ -0,0 +1,109 @@
+package qif
+
+import (
+	"testing"
+	"time"
+
+	"cashd/internal/data"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    time.Time
+		wantErr bool
+	}{
+		{s: "1/ 2'24", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{s: "12/31'99", want: time.Date(2099, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{s: "01/02/2024", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{s: "1/2/98", want: time.Date(1998, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{s: "1/2/24", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{s: "2024-01-02", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{s: "2024.1.2", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{s: "2/30/2024", wantErr: true},
+		{s: "13/1/2024", wantErr: true},
+		{s: "1/2", wantErr: true},
+		{s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDate(%q) error = %v, want error %v", tt.s, err, tt.wantErr)
+		} else if !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %s, want %s", tt.s, got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestParseQif(t *testing.T) {
+	content := `!Type:Bank
+D1/1'24
+T1,000.00
+PCheckbook
+L[Checking]
+^
+D1/5'24
+T-80.00
+PMarket
+LFood
+SFood
+$-50.00
+SHousehold
+$-30.00
+^
+D1/6'24
+T-20.00
+PPharmacy
+SHealth
+$-20.00
+^
+D1/7'24
+T200.00
+PTransfer from savings
+L[Savings]
+^
+D1/8'24
+Tabc
+PBroken
+^
+`
+	var problems []*data.ParseError
+	f, err := parseQif(content, "Checking.qif", func(parseErr *data.ParseError) error {
+		problems = append(problems, parseErr)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The transfer to the account itself is its opening balance, the day before
+	if len(f.balances) != 1 || f.balances[0].Account != "Checking" || f.balances[0].Amount != 100000 ||
+		!f.balances[0].Date.Equal(time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("balances = %+v, want $1,000.00 in Checking on 2023-12-31", f.balances)
+	}
+	if len(f.entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(f.entries))
+	}
+
+	split := f.entries[0].txn
+	if split.Type != data.Expense || split.Amount != 8000 || split.Category != "Food, Household" || len(split.Splits) != 2 {
+		t.Errorf("split record = %s %d %q %v, want an expense of 8000 in Food, Household with 2 splits",
+			split.Type, split.Amount, split.Category, split.Splits)
+	} else if split.Splits[0] != (data.Split{Type: data.Expense, Category: "Food", Amount: 5000}) {
+		t.Errorf("first split = %+v, want 5000 of Food", split.Splits[0])
+	}
+	// A single split is the category of the record
+	if single := f.entries[1].txn; single.Category != "Health" || single.Splits != nil {
+		t.Errorf("single split record = %q %v, want Health without splits", single.Category, single.Splits)
+	}
+
+	// Money coming in from a transfer is sent by the other account
+	inflow := f.entries[2]
+	if !inflow.inflow || inflow.txn.Type != data.Transfer || inflow.txn.Account != "Savings" || inflow.txn.ToAccount != "Checking" {
+		t.Errorf("transfer = %+v, want an inflow from Savings to Checking", inflow.txn)
+	}
+
+	if len(problems) != 1 || problems[0].Column != "T" || problems[0].Line != 27 || problems[0].File != "Checking.qif" {
+		t.Errorf("problems = %v, want the amount of the record at line 27", problems)
+	}
+}
@@ -0,0 +1,701 @@
+package data
+
+import (
+	"cashd/internal/date"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query is a parsed search query: keywords combined with AND, OR, NOT and parentheses,
+// e.g. (c:food OR c:dining) m:>50 -p:"whole foods"
+type Query struct {
+	// nil for an empty query, which matches all transactions
+	root queryNode
+}
+
+// A node of the syntax tree of a query
+type queryNode interface {
+	matches(t *Transaction) bool
+}
+
+type andNode []queryNode
+
+type orNode []queryNode
+
+type notNode struct {
+	node queryNode
+}
+
+func (n andNode) matches(t *Transaction) bool {
+	for _, node := range n {
+		if !node.matches(t) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n orNode) matches(t *Transaction) bool {
+	for _, node := range n {
+		if node.matches(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n notNode) matches(t *Transaction) bool {
+	return !n.node.matches(t)
+}
+
+// ParseQuery parses a search query, keywords next to each other must all match
+// OR binds looser than AND, e.g. c:food m:>10 OR c:groceries is (c:food AND m:>10) OR c:groceries
+// Relative dates, e.g. d:thismonth, are resolved when the query is parsed
+func ParseQuery(query string) (*Query, error) {
+	now := time.Now()
+	return parseQuery(query, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local))
+}
+
+// Parse a query with relative dates resolved against today
+func parseQuery(query string, today time.Time) (*Query, error) {
+	tokens, err := tokenizeQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return &Query{}, nil
+	}
+	p := &queryParser{tokens: tokens, today: today}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		// parseOr only stops early at a closing parenthesis
+		return nil, fmt.Errorf(`unexpected ")" without "("`)
+	}
+	return &Query{root: root}, nil
+}
+
+// Whether the transaction matches the query, a nil or empty query matches all transactions
+func (q *Query) Matches(t *Transaction) bool {
+	return q == nil || q.root == nil || q.root.matches(t)
+}
+
+// Return the transactions matching the query
+func (q *Query) Filter(transactions []*Transaction) []*Transaction {
+	if q == nil || q.root == nil {
+		return transactions
+	}
+	matchingTransactions := []*Transaction{}
+	for _, t := range transactions {
+		if q.root.matches(t) {
+			matchingTransactions = append(matchingTransactions, t)
+		}
+	}
+	return matchingTransactions
+}
+
+// Return transactions matching the search query, see ParseQuery
+func Search(transactions []*Transaction, query string) ([]*Transaction, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return q.Filter(transactions), nil
+}
+
+type tokenKind int
+
+const (
+	keywordToken tokenKind = iota
+	andToken
+	orToken
+	notToken
+	openToken
+	closeToken
+)
+
+type queryToken struct {
+	kind tokenKind
+	text string
+}
+
+// Split the query into keywords, operators and parentheses
+// Double quotes group words into one keyword and are removed, e.g. a:"boa checking", quoted AND, OR and NOT are keywords
+func tokenizeQuery(query string) ([]queryToken, error) {
+	tokens := []queryToken{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, queryToken{openToken, "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryToken{closeToken, ")"})
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '(':
+			// -(...) negates a group
+			tokens = append(tokens, queryToken{notToken, negativeKeywordPrefix})
+			i++
+		default:
+			var kw strings.Builder
+			quoted, wasQuoted := false, false
+			start := i
+			for ; i < len(runes); i++ {
+				r := runes[i]
+				if !quoted && (unicode.IsSpace(r) || r == '(' || r == ')') {
+					break
+				}
+				if r == '"' {
+					quoted = !quoted
+					wasQuoted = true
+					continue
+				}
+				if r == '/' && !quoted && regexStartRegexp.MatchString(kw.String()) {
+					// A regular expression can have spaces and parentheses, e.g. p:/^(amzn|amazon) mktp/
+					end := closingSlash(runes, i+1)
+					if end < 0 {
+						return nil, fmt.Errorf("missing closing / in %s", string(runes[start:]))
+					}
+					kw.WriteString(string(runes[i : end+1]))
+					i = end
+					continue
+				}
+				kw.WriteRune(r)
+			}
+			if quoted {
+				return nil, fmt.Errorf("missing closing quote in %s", string(runes[start:]))
+			}
+			token := queryToken{keywordToken, kw.String()}
+			if !wasQuoted {
+				switch token.text {
+				case "AND":
+					token.kind = andToken
+				case "OR":
+					token.kind = orToken
+				case "NOT":
+					token.kind = notToken
+				}
+			}
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+// Keyword text before a regular expression, e.g. p: or -p:
+var regexStartRegexp = regexp.MustCompile(`^-?(?:[a-zA-Z]:)?$`)
+
+// Return the index of the first / from start that is not escaped, -1 if there is none
+func closingSlash(runes []rune, start int) int {
+	for i := start; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case '/':
+			return i
+		}
+	}
+	return -1
+}
+
+// Recursive descent parser, from the loosest binding operator:
+//
+//	or      = and { "OR" and }
+//	and     = unary { ["AND"] unary }
+//	unary   = ("NOT" | "-") unary | primary
+//	primary = "(" or ")" | keyword
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+	today  time.Time
+}
+
+func (p *queryParser) next() (queryToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	node, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := orNode{node}
+	for {
+		if token, ok := p.next(); !ok || token.kind != orToken {
+			break
+		}
+		p.pos++
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	node, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	nodes := andNode{node}
+	for {
+		token, ok := p.next()
+		if !ok || token.kind == orToken || token.kind == closeToken {
+			break
+		}
+		if token.kind == andToken {
+			p.pos++
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	if token, ok := p.next(); ok && token.kind == notToken {
+		p.pos++
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	token, ok := p.next()
+	if !ok || token.kind != keywordToken && token.kind != openToken {
+		if token.kind == closeToken && p.pos == 0 {
+			return nil, fmt.Errorf(`unexpected ")" without "("`)
+		} else if p.pos == 0 {
+			return nil, fmt.Errorf("expected a keyword before %s", token.text)
+		}
+		return nil, fmt.Errorf("expected a keyword after %s", p.tokens[p.pos-1].text)
+	}
+	p.pos++
+	if token.kind == openToken {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if token, ok := p.next(); !ok || token.kind != closeToken {
+			return nil, fmt.Errorf(`missing ")"`)
+		}
+		p.pos++
+		return node, nil
+	}
+	if kw, negative := strings.CutPrefix(token.text, negativeKeywordPrefix); negative && kw != "" {
+		node, err := parseKeyword(kw, p.today)
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+	}
+	return parseKeyword(token.text, p.today)
+}
+
+const (
+	negativeKeywordPrefix = "-"
+	// A text keyword starting with = matches the whole field instead of a part of it, e.g. c:=food
+	exactKeywordPrefix = "="
+
+	kwPrefixDate     = "d:"
+	kwPrefixType     = "t:"
+	kwPrefixAccount  = "a:"
+	kwPrefixCategory = "c:"
+	kwPrefixAmount   = "m:"
+	kwPrefixDesc     = "p:"
+	kwPrefixSource   = "s:"
+	kwPrefixRule     = "r:"
+	kwPrefixTag      = "g:"
+)
+
+// Prefixes of keywords matching text fields
+var textKeywordPrefixes = []string{kwPrefixType, kwPrefixAccount, kwPrefixCategory, kwPrefixDesc, kwPrefixSource, kwPrefixRule, kwPrefixTag}
+
+type matchOp string
+
+const (
+	noneOp         matchOp = ""
+	equal          matchOp = "="
+	larger         matchOp = ">"
+	largerOrEqual  matchOp = ">="
+	smaller        matchOp = "<"
+	smallerOrEqual matchOp = "<="
+	// Inclusive range, either end can be left out, e.g. 100..500 or 2024-01..
+	between matchOp = ".."
+)
+
+// Longer operators first, >= would otherwise be read as > and =
+var comparisonOps = []matchOp{largerOrEqual, smallerOrEqual, larger, smaller, equal}
+
+// Split a value into its operator and operand, or the two ends of a range
+func splitComparison(value string) (matchOp, string, string) {
+	for _, op := range comparisonOps {
+		if operand, ok := strings.CutPrefix(value, string(op)); ok {
+			return op, operand, ""
+		}
+	}
+	if from, to, ok := strings.Cut(value, string(between)); ok {
+		return between, from, to
+	}
+	return noneOp, value, ""
+}
+
+var (
+	// Part of a date to match in the date of transactions, e.g. 2024-01 or 01-05, months and days have 2 digits
+	partialDateRegexp = regexp.MustCompile(`^(\d{4}(-\d{2}){0,2}|\d{2}(-\d{2})?)$`)
+	// Months and days without a year, e.g. 12 or 01-05, only match dates with d: as they look like amounts
+	monthDayRegexp = regexp.MustCompile(`^\d{2}(-\d{2})?$`)
+	// Keywords without a prefix only match dates written out, not e.g. relative dates
+	anyFieldDateRegexp = regexp.MustCompile(`^[<>=]*[0-9]`)
+	// The last N days, weeks, months or years up to today, e.g. last30d
+	lastNRegexp  = regexp.MustCompile(`^last(\d+)([dwmy])$`)
+	amountRegexp = regexp.MustCompile(`^[0-9]*\.?[0-9]+$`)
+
+	// Weekdays by their full and short names, e.g. saturday and sat
+	weekdays = func() map[string]time.Weekday {
+		weekdays := map[string]time.Weekday{}
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			name := strings.ToLower(d.String())
+			weekdays[name] = d
+			weekdays[name[:3]] = d
+		}
+		return weekdays
+	}()
+)
+
+// Parse a keyword, prefixed keywords match one field and others match any field
+// Relative dates are resolved against today
+func parseKeyword(kw string, today time.Time) (queryNode, error) {
+	prefix, value := "", kw
+	if len(kw) >= 2 && kw[1] == ':' {
+		p := strings.ToLower(kw[:2])
+		if p == kwPrefixDate || p == kwPrefixAmount || slices.Contains(textKeywordPrefixes, p) {
+			prefix, value = p, kw[2:]
+		}
+	}
+	if !isRegexKeyword(value) {
+		value = strings.ToLower(value)
+	}
+	switch prefix {
+	case kwPrefixDate:
+		return parseDateKeyword(kw, value, today)
+	case kwPrefixAmount:
+		return parseAmountKeyword(kw, value)
+	case "":
+	default:
+		return parseTextKeyword(kw, prefix, value)
+	}
+
+	// Without a prefix, fields that can't hold the keyword are skipped, e.g. dates for "coffee"
+	nodes := orNode{}
+	if anyFieldDateRegexp.MatchString(value) && !monthDayRegexp.MatchString(value) {
+		if node, err := parseDateKeyword(kw, value, today); err == nil {
+			nodes = append(nodes, node)
+		}
+	}
+	if node, err := parseAmountKeyword(kw, value); err == nil {
+		nodes = append(nodes, node)
+	}
+	for _, prefix := range textKeywordPrefixes {
+		node, err := parseTextKeyword(kw, prefix, value)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// A keyword value between slashes is a regular expression, e.g. /^amzn.*mktp/
+func isRegexKeyword(value string) bool {
+	return len(value) >= 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/")
+}
+
+type textKeyword struct {
+	prefix string
+	value  string
+	exact  bool
+	// Case-insensitive, nil unless the value is a regular expression
+	re *regexp.Regexp
+}
+
+func parseTextKeyword(kw, prefix, value string) (queryNode, error) {
+	if isRegexKeyword(value) {
+		re, err := regexp.Compile("(?i)" + value[1:len(value)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression in %s: %w", kw, err)
+		}
+		return textKeyword{prefix: prefix, re: re}, nil
+	}
+	value, exact := strings.CutPrefix(value, exactKeywordPrefix)
+	if value == "" {
+		return nil, fmt.Errorf("missing value in %s", kw)
+	}
+	return textKeyword{prefix: prefix, value: value, exact: exact}, nil
+}
+
+func (k textKeyword) matches(t *Transaction) bool {
+	for _, s := range t.searchFields(k.prefix) {
+		if k.re != nil {
+			if k.re.MatchString(s) {
+				return true
+			}
+			continue
+		}
+		s = strings.ToLower(s)
+		if k.exact && s == k.value || !k.exact && strings.Contains(s, k.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the values of the transaction a text keyword prefix matches, e.g. the account and destination account for a:
+func (t *Transaction) searchFields(prefix string) []string {
+	switch prefix {
+	case kwPrefixType:
+		fields := []string{string(t.Type)}
+		for _, s := range t.CategorySplits() {
+			fields = append(fields, string(s.Type))
+		}
+		return fields
+	case kwPrefixAccount:
+		if t.IsTransfer() {
+			return []string{t.Account, t.ToAccount}
+		}
+		return []string{t.Account}
+	case kwPrefixCategory:
+		fields := []string{}
+		for _, s := range t.CategorySplits() {
+			fields = append(fields, s.Category)
+		}
+		return fields
+	case kwPrefixDesc:
+		return []string{t.Description}
+	case kwPrefixSource:
+		return []string{t.Source}
+	case kwPrefixRule:
+		return []string{t.Rule}
+	case kwPrefixTag:
+		// Tags with a value match as tag:value, e.g. g:project, g:kitchen or g:project:kitchen
+		return t.AllTags()
+	default:
+		panic(fmt.Sprintf("unexpected search keyword prefix: %s", prefix))
+	}
+}
+
+// Dates are compared by the whole day, month, quarter or year they name, e.g. d:>2023 is from 2024 on
+type dateKeyword struct {
+	op matchOp
+	// Matched as a part of the date if there is no operator
+	value string
+	// First day of the period and the day after it, zero for an open end of a range
+	start, end time.Time
+}
+
+func parseDateKeyword(kw, value string, today time.Time) (queryNode, error) {
+	op, from, to := splitComparison(value)
+	if weekday, ok := weekdays[from]; ok {
+		if op != noneOp {
+			return nil, fmt.Errorf("%s can only match a weekday, e.g. d:sat", kw)
+		}
+		return weekdayKeyword(weekday), nil
+	}
+	if op == noneOp && partialDateRegexp.MatchString(from) {
+		// No operator, just string matching
+		return dateKeyword{op: noneOp, value: from}, nil
+	}
+
+	k := dateKeyword{op: op}
+	var err error
+	if op != between {
+		if op == noneOp {
+			// A period or relative date, e.g. 2024-Q1 or thismonth
+			k.op = equal
+		}
+		k.start, k.end, err = parseDatePeriod(from, today)
+	} else if from == "" && to == "" {
+		return nil, fmt.Errorf("missing dates in %s", kw)
+	} else {
+		if from != "" {
+			k.start, _, err = parseDatePeriod(from, today)
+		}
+		if to != "" && err == nil {
+			_, k.end, err = parseDatePeriod(to, today)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid date in %s, expecting e.g. 2024-01-05, 2024-03, 2024-Q1, thismonth or last30d", kw)
+	}
+	return k, nil
+}
+
+// Return the first day of a date, period or relative date and the day after it, e.g. 2024-01-05, 2024-03, 2024-Q1,
+// 2024-W05, 2024, today, yesterday, thisweek, lastmonth, thisquarter, lastyear or last30d
+func parseDatePeriod(value string, today time.Time) (time.Time, time.Time, error) {
+	// Transaction dates are in the local time zone
+	if d, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return d, d.AddDate(0, 0, 1), nil
+	}
+	if start, inc, err := date.ParsePeriod(value); err == nil {
+		return start, inc.AddIncrement(start), nil
+	}
+	switch value {
+	case "today":
+		return today, today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), today, nil
+	}
+	for _, relative := range []string{"this", "last"} {
+		unit, ok := strings.CutPrefix(value, relative)
+		if !ok {
+			continue
+		}
+		if inc, err := date.ParseIncrement(unit); err == nil && inc != date.AllTime {
+			start := date.ToLocal(inc.FirstDayInIncrement(today))
+			if relative == "last" {
+				start = inc.SubtractIncrement(start)
+			}
+			return start, inc.AddIncrement(start), nil
+		}
+	}
+	if matches := lastNRegexp.FindStringSubmatch(value); matches != nil {
+		n, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		// Up to and including today
+		end := today.AddDate(0, 0, 1)
+		switch matches[2] {
+		case "d":
+			return end.AddDate(0, 0, -n), end, nil
+		case "w":
+			return end.AddDate(0, 0, -7*n), end, nil
+		case "m":
+			return end.AddDate(0, -n, 0), end, nil
+		case "y":
+			return end.AddDate(-n, 0, 0), end, nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+func (k dateKeyword) matches(t *Transaction) bool {
+	switch k.op {
+	case noneOp:
+		return strings.Contains(t.Date.Format(time.DateOnly), k.value)
+	case equal:
+		return !t.Date.Before(k.start) && t.Date.Before(k.end)
+	case larger:
+		return !t.Date.Before(k.end)
+	case largerOrEqual:
+		return !t.Date.Before(k.start)
+	case smaller:
+		return t.Date.Before(k.start)
+	case smallerOrEqual:
+		return t.Date.Before(k.end)
+	case between:
+		return (k.start.IsZero() || !t.Date.Before(k.start)) && (k.end.IsZero() || t.Date.Before(k.end))
+	default:
+		panic(fmt.Sprintf("unexpected search keyword operator: %s", k.op))
+	}
+}
+
+type weekdayKeyword time.Weekday
+
+func (k weekdayKeyword) matches(t *Transaction) bool {
+	return t.Date.Weekday() == time.Weekday(k)
+}
+
+type amountKeyword struct {
+	op matchOp
+	// Matched as a part of the amount if there is no operator
+	value  string
+	amount Money
+	// Bounds of a range
+	min, max Money
+}
+
+func parseAmountKeyword(kw, value string) (queryNode, error) {
+	op, from, to := splitComparison(value)
+	k := amountKeyword{op: op, value: from, min: math.MinInt64, max: math.MaxInt64}
+	var err error
+	if op != between {
+		k.amount, err = parseAmountOperand(kw, from)
+	} else if from == "" && to == "" {
+		return nil, fmt.Errorf("missing amounts in %s", kw)
+	} else {
+		if from != "" {
+			k.min, err = parseAmountOperand(kw, from)
+		}
+		if to != "" && err == nil {
+			k.max, err = parseAmountOperand(kw, to)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func parseAmountOperand(kw, s string) (Money, error) {
+	if !amountRegexp.MatchString(s) {
+		return 0, fmt.Errorf("invalid amount in %s, expecting e.g. 50, >50, <=50.25 or 100..500", kw)
+	}
+	amount, err := ParseMoney(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount in %s: %w", kw, err)
+	}
+	return amount, nil
+}
+
+func (k amountKeyword) matches(t *Transaction) bool {
+	switch k.op {
+	case noneOp:
+		// No operator, just string matching
+		return strings.Contains(t.Amount.String(), k.value)
+	case equal:
+		return t.Amount == k.amount
+	case larger:
+		return t.Amount > k.amount
+	case largerOrEqual:
+		return t.Amount >= k.amount
+	case smaller:
+		return t.Amount < k.amount
+	case smallerOrEqual:
+		return t.Amount <= k.amount
+	case between:
+		return t.Amount >= k.min && t.Amount <= k.max
+	default:
+		panic(fmt.Sprintf("unexpected search keyword operator: %s", k.op))
+	}
+}
@@ -0,0 +1,167 @@
+package data
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Transactions to search, identified by their descriptions
+var queryTransactions = []*Transaction{
+	{Date: queryDate(2024, 1, 5), Type: Expense, Account: "Checking", Category: "Food:Groceries", Amount: 5025, Description: "Whole Foods Market", Source: "csv"},
+	{Date: queryDate(2024, 2, 10), Type: Expense, Account: "BoA Visa", AccountType: AcctCreditCard, Category: "Dining", Amount: 1200, Description: "AMZN Mktp", Source: "ledger", Tags: []string{"work"}},
+	{Date: queryDate(2024, 3, 1), Type: Income, Account: "Checking", Category: "Salary", Amount: 300000, Description: "Paycheck", Source: "ledger", Rule: "salary"},
+	{Date: queryDate(2024, 3, 9), Type: Transfer, Account: "Checking", ToAccount: "Savings", Amount: 20000, Description: "Savings", Source: "ledger"},
+	{Date: queryDate(2024, 3, 14), Type: Expense, Account: "Cash", Category: "Food:Dining Out", Amount: 850, Description: "Taco (and more) stand", Source: "csv",
+		Metadata: map[string]string{"project": "kitchen"}},
+}
+
+func queryDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
+
+func TestParseQuery(t *testing.T) {
+	// A Friday
+	today := queryDate(2024, 3, 15)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "Whole Foods Market, AMZN Mktp, Paycheck, Savings, Taco (and more) stand"},
+		{"food", "Whole Foods Market, Taco (and more) stand"},
+		{"c:food", "Whole Foods Market, Taco (and more) stand"},
+		{"C:FOOD", "Whole Foods Market, Taco (and more) stand"},
+		{"c:=dining", "AMZN Mktp"},
+		{"a:savings", "Savings"},
+		{`a:"boa visa"`, "AMZN Mktp"},
+		{"t:income", "Paycheck"},
+		{"s:csv", "Whole Foods Market, Taco (and more) stand"},
+		{"r:salary", "Paycheck"},
+		{"g:work", "AMZN Mktp"},
+		{"g:project:kitchen", "Taco (and more) stand"},
+		{`p:"(and more)"`, "Taco (and more) stand"},
+		{"-c:food", "AMZN Mktp, Paycheck, Savings"},
+		{"NOT c:food", "AMZN Mktp, Paycheck, Savings"},
+		{"c:food m:>10", "Whole Foods Market"},
+		{"c:food AND m:>10", "Whole Foods Market"},
+		{"c:dining OR c:salary", "AMZN Mktp, Paycheck, Taco (and more) stand"},
+		{"c:food m:>10 OR c:salary", "Whole Foods Market, Paycheck"},
+		{"c:food (m:>10 OR a:cash)", "Whole Foods Market, Taco (and more) stand"},
+		{"-(c:food OR t:transfer)", "AMZN Mktp, Paycheck"},
+		// A quoted operator is a keyword, found in the tag work and the description
+		{`"OR"`, "AMZN Mktp, Taco (and more) stand"},
+		{"p:/^(amzn|whole) /", "Whole Foods Market, AMZN Mktp"},
+		{"-p:/^(amzn|whole) /", "Paycheck, Savings, Taco (and more) stand"},
+		{"m:12", "AMZN Mktp"},
+		{"m:=12", "AMZN Mktp"},
+		{"m:>=50.25", "Whole Foods Market, Paycheck, Savings"},
+		{"m:<12", "Taco (and more) stand"},
+		{"m:<=12", "AMZN Mktp, Taco (and more) stand"},
+		{"m:10..200", "Whole Foods Market, AMZN Mktp, Savings"},
+		{"m:..12", "AMZN Mktp, Taco (and more) stand"},
+		{"m:1000..", "Paycheck"},
+		{"d:2024-03", "Paycheck, Savings, Taco (and more) stand"},
+		{"d:03-01", "Paycheck"},
+		{"d:2024-01-05", "Whole Foods Market"},
+		{"d:=2024-Q1", "Whole Foods Market, AMZN Mktp, Paycheck, Savings, Taco (and more) stand"},
+		{"d:>2024-02", "Paycheck, Savings, Taco (and more) stand"},
+		{"d:>=2024-02-10", "AMZN Mktp, Paycheck, Savings, Taco (and more) stand"},
+		{"d:<2024-02-10", "Whole Foods Market"},
+		{"d:<=2024-02", "Whole Foods Market, AMZN Mktp"},
+		{"d:2024-02..2024-03-09", "AMZN Mktp, Paycheck, Savings"},
+		{"d:2024-03-09..", "Savings, Taco (and more) stand"},
+		{"d:2024-W10", "Savings"},
+		{"d:thismonth", "Paycheck, Savings, Taco (and more) stand"},
+		{"d:lastmonth", "AMZN Mktp"},
+		{"d:yesterday", "Taco (and more) stand"},
+		{"d:last7d", "Savings, Taco (and more) stand"},
+		{"d:sat", "AMZN Mktp, Savings"},
+		{"d:friday", "Whole Foods Market, Paycheck"},
+		{"2024-03-01", "Paycheck"},
+		{">2024-03-09", "Taco (and more) stand"},
+		// Months and days without a year are amounts unless prefixed with d:
+		{"d:10", "AMZN Mktp"},
+		{"10", ""},
+		{"12", "AMZN Mktp"},
+		{"d:03-14", "Taco (and more) stand"},
+		{"03-14", ""},
+	}
+	for _, tt := range tests {
+		q, err := parseQuery(tt.query, today)
+		if err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		got := []string{}
+		for _, t := range q.Filter(queryTransactions) {
+			got = append(got, t.Description)
+		}
+		if strings.Join(got, ", ") != tt.want {
+			t.Errorf("%s matches %q, want %q", tt.query, strings.Join(got, ", "), tt.want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{"(c:food", `missing ")"`},
+		{"c:food)", `unexpected ")"`},
+		{`p:"whole foods`, "missing closing quote"},
+		{"p:/amzn", "missing closing /"},
+		{"p:/[/", "invalid regular expression"},
+		{"c:", "missing value"},
+		{"c:=", "missing value"},
+		{"m:>abc", "invalid amount"},
+		{"m:..", "missing amounts"},
+		{"d:..", "missing dates"},
+		{"d:2024-1", "invalid date"},
+		{"d:2024-01-5", "invalid date"},
+		{"d:1-5", "invalid date"},
+		{"d:>thisdecade", "invalid date"},
+		{"d:>sat", "can only match a weekday"},
+		{"c:food OR", "expected a keyword after OR"},
+		{"NOT", "expected a keyword after NOT"},
+	}
+	for _, tt := range tests {
+		_, err := parseQuery(tt.query, queryDate(2024, 3, 15))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got error %v, want %q", tt.query, err, tt.err)
+		}
+	}
+}
+
+func TestDrillDownQueries(t *testing.T) {
+	transactions := []*Transaction{
+		{Type: Expense, Account: "Checking", Category: "Food", Amount: 100},
+		{Type: Expense, Account: "BoA Checking", Category: "Food:Groceries", Amount: 200},
+		{Type: Expense, Account: "Checking", Category: "Seafood", Amount: 300},
+		{Type: Expense, Account: "Checking", Category: "Food (out)", Amount: 400},
+	}
+	tests := []struct {
+		query string
+		want  []Money
+	}{
+		{AccountQuery("Checking"), []Money{100, 300, 400}},
+		{AccountQuery("BoA Checking"), []Money{200}},
+		{CategoryQuery("Food"), []Money{100, 200}},
+		{CategoryQuery("Food:Groceries"), []Money{200}},
+		{CategoryQuery("Food (out)"), []Money{400}},
+	}
+	for _, tt := range tests {
+		got, err := Search(transactions, tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		amounts := []Money{}
+		for _, t := range got {
+			amounts = append(amounts, t.Amount)
+		}
+		if !slices.Equal(amounts, tt.want) {
+			t.Errorf("%s matches %v, want %v", tt.query, amounts, tt.want)
+		}
+	}
+}
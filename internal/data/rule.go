@@ -0,0 +1,149 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Rule changes the transactions matching a search query, e.g. to categorize bank transactions
+type Rule struct {
+	// Defaults to the position of the rule in the file, e.g. "rule 3"
+	Name string `json:"name"`
+	// Search query, e.g. "p:starbucks m:<20"
+	Match string `json:"match"`
+
+	// Actions, empty fields are left unchanged
+	Category string `json:"category"`
+	Account  string `json:"account"`
+	// Type of the account set by the rule, defaults to the type the account has in other transactions
+	AccountType AccountType     `json:"account_type"`
+	Type        TransactionType `json:"type"`
+	Description string          `json:"description"`
+
+	query *Query
+}
+
+const rulesFileName = "rules.json"
+
+var rulesFileFlag string
+
+func init() {
+	pflag.StringVar(&rulesFileFlag, "rules", "", fmt.Sprintf("Rules file path, defaults to ~/.config/cashd/%s", rulesFileName))
+}
+
+// Load rules from the file specified by --rules, or the default rules file if it exists
+func LoadRules() ([]Rule, error) {
+	path := rulesFileFlag
+	if path == "" {
+		path = filepath.Join(configDir, rulesFileName)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && rulesFileFlag == "" {
+			return []Rule{}, nil
+		}
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(content, &rules); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rules from %s: %w", path, err)
+	}
+	for i := range rules {
+		r := &rules[i]
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if strings.TrimSpace(r.Match) == "" {
+			return nil, fmt.Errorf("%s in %s has no match query", r.Name, path)
+		} else if r.Category == "" && r.Account == "" && r.Type == "" && r.Description == "" {
+			return nil, fmt.Errorf("%s in %s has no action, expecting category, account, type or description", r.Name, path)
+		} else if r.AccountType != "" && r.Account == "" {
+			return nil, fmt.Errorf("%s in %s has an account type without an account", r.Name, path)
+		} else if r.Type == Transfer {
+			return nil, fmt.Errorf("%s in %s turns transactions into transfers, which need a destination account", r.Name, path)
+		}
+		query, err := ParseQuery(r.Match)
+		if err != nil {
+			return nil, fmt.Errorf("%s in %s has an invalid match query: %w", r.Name, path, err)
+		}
+		r.query = query
+	}
+	return rules, nil
+}
+
+// ApplyRules changes each transaction with the first rule it matches, and records the rule in the transaction
+// A change that makes the transaction invalid, e.g. a transfer without a category, is logged and not applied
+func ApplyRules(transactions []*Transaction, rules []Rule) {
+	// Rules moving transactions to an account give them its type
+	accountTypes := map[string]AccountType{}
+	for _, t := range transactions {
+		accountTypes[t.Account] = t.AccountType
+		if t.IsTransfer() {
+			accountTypes[t.ToAccount] = t.ToAccountType
+		}
+	}
+	for _, t := range transactions {
+		for _, r := range rules {
+			if !r.query.Matches(t) {
+				continue
+			}
+			changed := *t
+			r.apply(&changed, accountTypes)
+			if err := changed.Validate(); err != nil {
+				log.Printf("%s is not applied to %s on %s: %v\n", r.Name, t.Description, t.Date.Format(time.DateOnly), err)
+			} else {
+				*t = changed
+				t.Rule = r.Name
+			}
+			break
+		}
+	}
+}
+
+func (r *Rule) apply(t *Transaction, accountTypes map[string]AccountType) {
+	if r.Type != "" && r.Type != t.Type {
+		// Income and expense splits can't follow the new type, nor can the categories joined from them
+		t.Type = r.Type
+		t.ToAccount, t.ToAccountType = "", ""
+		if len(t.Splits) > 0 {
+			t.Category = Uncategorized
+		}
+		t.Splits = nil
+	}
+	if r.Category != "" {
+		// The category applies to the whole amount
+		t.Category = r.Category
+		t.Splits = nil
+	}
+	if r.Account != "" {
+		t.Account = r.Account
+		if r.AccountType != "" {
+			t.AccountType = r.AccountType
+		} else if accountType, ok := accountTypes[r.Account]; ok {
+			t.AccountType = accountType
+		}
+	}
+	if r.Description != "" {
+		t.Description = r.Description
+	}
+}
+
+// Return the transactions no rule was applied to
+func Unmatched(transactions []*Transaction) []*Transaction {
+	unmatched := []*Transaction{}
+	for _, t := range transactions {
+		if t.Rule == "" {
+			unmatched = append(unmatched, t)
+		}
+	}
+	return unmatched
+}
@@ -0,0 +1,40 @@
+package data
+
+import "testing"
+
+func TestApplyRules(t *testing.T) {
+	parseRule := func(r Rule) Rule {
+		query, err := ParseQuery(r.Match)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.query = query
+		return r
+	}
+	card := &Transaction{Date: queryDate(2024, 1, 5), Type: Expense, Account: "Visa", AccountType: AcctCreditCard, Category: "Fuel", Amount: 4000, Description: "Shell"}
+	cash := &Transaction{Date: queryDate(2024, 1, 5), Type: Expense, Account: "Wallet", AccountType: AcctCash, Category: "Food", Amount: 1200, Description: "Deli"}
+	split := &Transaction{Date: queryDate(2024, 1, 5), Type: Expense, Account: "Checking", AccountType: AcctBankAccount, Category: "Food, Household", Amount: 8000,
+		Description: "Market", Splits: []Split{{Type: Expense, Category: "Food", Amount: 5000}, {Type: Expense, Category: "Household", Amount: 3000}}}
+	refund := &Transaction{Date: queryDate(2024, 1, 5), Type: Expense, Account: "Checking", AccountType: AcctBankAccount, Category: "Food, Household", Amount: 2000,
+		Description: "Refund", Splits: []Split{{Type: Expense, Category: "Food", Amount: 1000}, {Type: Expense, Category: "Household", Amount: 1000}}}
+	rules := []Rule{
+		parseRule(Rule{Name: "deli", Match: "p:deli", Account: "Visa"}),
+		parseRule(Rule{Name: "shell", Match: "p:shell", Account: "Gas Card", AccountType: AcctCreditCard}),
+		parseRule(Rule{Name: "market", Match: "p:market", Type: Income}),
+		parseRule(Rule{Name: "refund", Match: "p:refund", Type: Income, Category: "Refunds"}),
+	}
+	ApplyRules([]*Transaction{card, cash, split, refund}, rules)
+
+	if cash.Account != "Visa" || cash.AccountType != AcctCreditCard {
+		t.Errorf("deli account = %s %s, want the credit card Visa", cash.Account, cash.AccountType)
+	}
+	if card.Account != "Gas Card" || card.AccountType != AcctCreditCard {
+		t.Errorf("shell account = %s %s, want the credit card Gas Card", card.Account, card.AccountType)
+	}
+	if split.Type != Income || split.Category != Uncategorized || split.Splits != nil {
+		t.Errorf("market = %s %q %v, want an uncategorized income without splits", split.Type, split.Category, split.Splits)
+	}
+	if refund.Type != Income || refund.Category != "Refunds" || refund.Splits != nil {
+		t.Errorf("refund = %s %q %v, want an income in Refunds without splits", refund.Type, refund.Category, refund.Splits)
+	}
+}
@@ -18,7 +18,7 @@ type SavedSearch struct {
 
 const savedSearchFileName = "saved_search.json"
 
-var savedSearchDir = func() string {
+var configDir = func() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		log.Printf("could not determine home directory, using relative path for cache: %+v\n", err)
@@ -28,7 +28,7 @@ var savedSearchDir = func() string {
 }()
 
 var savedSearchPath = func() string {
-	return filepath.Join(savedSearchDir, savedSearchFileName)
+	return filepath.Join(configDir, savedSearchFileName)
 }()
 
 var loaded bool
@@ -62,7 +62,7 @@ func saveSavedSearches(s []SavedSearch) error {
 		return fmt.Errorf("failed to marshal saved searches: %w", err)
 	}
 
-	if err := os.MkdirAll(savedSearchDir, 0755); err == nil {
+	if err := os.MkdirAll(configDir, 0755); err == nil {
 		if err := os.WriteFile(savedSearchPath, data, 0644); err != nil {
 			// Log caching error but don't fail the request
 			log.Printf("Failed to write to %s: %+v", savedSearchPath, err)
@@ -3,9 +3,10 @@ package data
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"reflect"
 	"regexp"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
 )
@@ -13,14 +14,16 @@ import (
 type TransactionType string
 
 const (
-	// TODO: support more transaction types
 	Income  TransactionType = "Income"
 	Expense TransactionType = "Expense"
+	// Money moved from Account to ToAccount, neither income nor expense
+	Transfer TransactionType = "Transfer"
 )
 
 const (
-	incomeSymbol = "󱙹"
-	expensSymbol = ""
+	incomeSymbol   = "󱙹"
+	expensSymbol   = ""
+	transferSymbol = ""
 )
 
 func (t *TransactionType) UnmarshalJSON(data []byte) error {
@@ -29,7 +32,7 @@ func (t *TransactionType) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("failed to unmarshal TransactionType: %w", err)
 	}
 
-	if s == string(Income) || s == string(Expense) {
+	if s == string(Income) || s == string(Expense) || s == string(Transfer) {
 		*t = TransactionType(s)
 		return nil
 	} else {
@@ -67,27 +70,69 @@ func (a *AccountType) UnmarshalJSON(data []byte) error {
 	}
 }
 
+// Category of transactions from sources without categories, e.g. bank statements
+const Uncategorized = "Uncategorized"
+
 type Transaction struct {
 	Date        time.Time
 	Type        TransactionType
 	AccountType AccountType
 	Account     string
 	Category    string
-	Amount      float64
+	Amount      Money
 	Description string
+	// Destination of a transfer, empty for other transaction types
+	ToAccountType AccountType
+	ToAccount     string
+	// Currency of Amount, empty means DefaultCurrency
+	Currency string
+	// Tags without a value, e.g. vacation
+	Tags []string
+	// Tags with a value, e.g. project: kitchen
+	Metadata map[string]string `field:"-"`
+	// Amount and currency before conversion to the reporting currency, only set when converted
+	OriginalAmount   Money  `field:"-"`
+	OriginalCurrency string `field:"-"`
+	// Breakdown of the amount by category, only set when there is more than one category
+	Splits []Split `field:"-"`
+	// Name of the data source the transaction is loaded from
+	Source string `field:"-"`
+	// File and line the transaction is loaded from, empty and 0 when unknown, e.g. when read through ledger print
+	File string `field:"-"`
+	Line int    `field:"-"`
+	// Name of the rule that changed the transaction, empty if no rule matched
+	Rule string `field:"-"`
+}
+
+// Split is the part of a transaction attributed to a single category
+type Split struct {
+	Type     TransactionType
+	Category string
+	Amount   Money
 }
 
 type TransactionField string
 
+// Transaction fields that can be loaded from a data source, fields tagged with `field:"-"` are excluded
 var AllTransactionFields = func() []TransactionField {
 	fields := []TransactionField{}
 	t := reflect.TypeOf(Transaction{})
 	for i := range t.NumField() {
+		if t.Field(i).Tag.Get("field") == "-" {
+			continue
+		}
 		fields = append(fields, TransactionField(t.Field(i).Name))
 	}
 	return fields
 }()
 
+// Whether the field can be missing from a data source
+// Account types can be inferred from account names, only transfers have a destination account,
+// currency defaults to DefaultCurrency and tags are optional
+func (f TransactionField) Optional() bool {
+	return f == "AccountType" || f == "ToAccountType" || f == "ToAccount" || f == "Currency" || f == "Tags"
+}
+
 func (t *TransactionField) UnmarshalJSON(data []byte) error {
 	var s string
 	if err := json.Unmarshal(data, &s); err != nil {
@@ -104,28 +149,137 @@ func (t *TransactionField) UnmarshalJSON(data []byte) error {
 }
 
 func (t *Transaction) IsValid() bool {
-	return !t.Date.IsZero() &&
-		t.Type != "" &&
-		t.AccountType != "" &&
-		t.Account != "" &&
-		t.Category != "" &&
-		t.Amount > 0 &&
-		t.Description != ""
+	return t.Validate() == nil
+}
+
+// Return why the transaction is incomplete, or nil if it is valid
+func (t *Transaction) Validate() error {
+	switch {
+	case t.Date.IsZero():
+		return fmt.Errorf("missing date")
+	case t.Type == "":
+		return fmt.Errorf("missing transaction type")
+	case t.Account == "":
+		return fmt.Errorf("missing account")
+	case t.AccountType == "":
+		return fmt.Errorf("missing account type")
+	case t.Amount <= 0:
+		return fmt.Errorf("amount must be positive")
+	case t.Description == "":
+		return fmt.Errorf("missing description")
+	}
+	if t.Type == Transfer {
+		// Transfers have a destination account instead of a category
+		if t.ToAccount == "" || t.ToAccountType == "" {
+			return fmt.Errorf("transfer is missing the destination account")
+		} else if t.ToAccount == t.Account {
+			return fmt.Errorf("transfer to the same account")
+		}
+	} else if t.Category == "" {
+		return fmt.Errorf("missing category")
+	}
+	return nil
+}
+
+func (t *Transaction) IsTransfer() bool {
+	return t.Type == Transfer
+}
+
+// Whether the transaction moves money in or out of the account
+func (t *Transaction) InvolvesAccount(account string) bool {
+	return t.Account == account || (t.IsTransfer() && t.ToAccount == account)
+}
+
+// Return the amount of each category in the transaction
+// A transaction without splits has a single split covering the whole amount
+// Transfers are neither income nor expense so they have no splits
+func (t *Transaction) CategorySplits() []Split {
+	if len(t.Splits) > 0 {
+		return t.Splits
+	} else if t.IsTransfer() {
+		return nil
+	}
+	return []Split{{Type: t.Type, Category: t.Category, Amount: t.Amount}}
+}
+
+// Return the splits in the given category or its descendants
+func (t *Transaction) SplitsInCategory(category string) []Split {
+	splits := []Split{}
+	for _, s := range t.CategorySplits() {
+		if InCategory(s.Category, category) {
+			splits = append(splits, s)
+		}
+	}
+	return splits
+}
+
+// Separates the levels of a category, e.g. Food:Restaurants is a child of Food
+const CategorySeparator = ":"
+
+// Return the parent of a category, empty for a top-level category
+func ParentCategory(category string) string {
+	if i := strings.LastIndex(category, CategorySeparator); i >= 0 {
+		return category[:i]
+	}
+	return ""
+}
+
+// Whether the category is the ancestor or one of its descendants
+func InCategory(category, ancestor string) bool {
+	return category == ancestor || strings.HasPrefix(category, ancestor+CategorySeparator)
+}
+
+// Add a tag, or a tag with a value when value is not empty
+func (t *Transaction) AddTag(tag, value string) {
+	if tag == "" {
+		return
+	}
+	if value != "" {
+		if t.Metadata == nil {
+			t.Metadata = map[string]string{}
+		}
+		t.Metadata[tag] = value
+	} else if !slices.Contains(t.Tags, tag) {
+		t.Tags = append(t.Tags, tag)
+	}
+}
+
+// Return the tags of the transaction, tags with a value are written as tag:value, e.g. project:kitchen
+func (t *Transaction) AllTags() []string {
+	tags := slices.Clone(t.Tags)
+	for _, tag := range slices.Sorted(maps.Keys(t.Metadata)) {
+		tags = append(tags, tag+":"+t.Metadata[tag])
+	}
+	return tags
+}
+
+func (t *Transaction) HasTag(tag string) bool {
+	return slices.Contains(t.AllTags(), tag)
 }
 
 func (t *Transaction) Symbol() string {
-	switch t.Type {
+	return TransactionTypeSymbol(t.Type)
+}
+
+func TransactionTypeSymbol(t TransactionType) string {
+	switch t {
 	case Income:
 		return incomeSymbol
 	case Expense:
 		return expensSymbol
+	case Transfer:
+		return transferSymbol
 	default:
 		return ""
 	}
 }
 
 func (t *Transaction) AccountSymbol() string {
-	switch t.AccountType {
+	return AccountTypeSymbol(t.AccountType)
+}
+
+func AccountTypeSymbol(a AccountType) string {
+	switch a {
 	case AcctCash:
 		return cashSymbol
 	case AcctBankAccount:
@@ -138,169 +292,55 @@ func (t *Transaction) AccountSymbol() string {
 }
 
 func (t *Transaction) FormattedAmount() string {
-	return FormatMoney(t.Amount)
+	return FormatAmount(t.Amount, t.Currency)
 }
 
-func (t *Transaction) Matches(kws []string) bool {
-	for _, kw := range kws {
-		// Requires the transaction to contain ALL keywords
-		// So we can return false on any unmatched keyword
-		if kw, negative := strings.CutPrefix(kw, negativeKeywordPrefix); negative {
-			if t.matchKeyword(kw) {
-				return false
-			}
-		} else if !t.matchKeyword(kw) {
-			return false
-		}
-	}
-	return true
+// Whether the transaction was converted from another currency
+func (t *Transaction) IsConverted() bool {
+	return t.OriginalCurrency != ""
 }
 
-func (t *Transaction) matchKeyword(kw string) bool {
-	if trimmed, hasPrefix := strings.CutPrefix(kw, kwPrefixDate); hasPrefix {
-		return t.matchesDate(trimmed)
-	}
-	if trimmed, hasPrefix := strings.CutPrefix(kw, kwPrefixType); hasPrefix {
-		return t.matchesType(trimmed)
+// Return the currency of the transaction in its data source, before conversion
+func (t *Transaction) SourceCurrency() string {
+	if t.IsConverted() {
+		return t.OriginalCurrency
 	}
-	if trimmed, hasPrefix := strings.CutPrefix(kw, kwPrefixAccount); hasPrefix {
-		return t.matchesAccount(trimmed)
-	}
-	if trimmed, hasPrefix := strings.CutPrefix(kw, kwPrefixCategory); hasPrefix {
-		return t.matchesCategory(trimmed)
-	}
-	if trimmed, hasPrefix := strings.CutPrefix(kw, kwPrefixAmount); hasPrefix {
-		return t.matchesAmount(trimmed)
-	}
-	if trimmed, hasPrefix := strings.CutPrefix(kw, kwPrefixDesc); hasPrefix {
-		return t.matchesDescription(trimmed)
-	}
-	// Check all fields for unprefix keywords
-	return t.matchesDate(kw) ||
-		t.matchesType(kw) ||
-		t.matchesAccount(kw) ||
-		t.matchesCategory(kw) ||
-		t.matchesAmount(kw) ||
-		t.matchesDescription(kw)
+	return t.Currency
 }
 
-const (
-	negativeKeywordPrefix = "-"
-
-	kwPrefixDate     = "d:"
-	kwPrefixType     = "t:"
-	kwPrefixAccount  = "a:"
-	kwPrefixCategory = "c:"
-	kwPrefixAmount   = "m:"
-	kwPrefixDesc     = "p:"
-)
-
-type matchOp string
-
-const (
-	noneOp  matchOp = ""
-	larger  matchOp = ">"
-	smaller matchOp = "<"
-)
-
-var (
-	dateFormats = []string{"2006-01-02", "2006-01", "2006"}
-	// Matches optional < or >, then date in one of the formats anove
-	dateRegexp = regexp.MustCompile(`^([<>])?(\d{4}(?:-\d{2}(?:-\d{2})?)?)$`)
-	// Matches optional < or >, then a float number
-	amountRegexp = regexp.MustCompile(`^([<>])?([0-9]*\.?[0-9]+)?$`)
-)
-
-func (t *Transaction) matchesDate(kw string) bool {
-	op, dateStr := parseDateKeyword(kw)
-	if op == noneOp {
-		// No operator, just string matching
-		if dateStr != "" {
-			return strings.Contains(t.Date.Format(dateFormats[0]), dateStr)
-		} else {
-			return false
-		}
-	} else {
-		// Date comparison using the operator
-		date := parseDate(dateStr)
-		if date == nil {
-			// Should not happen since date format is ensured by regexp
-			return false
-		}
-		switch op {
-		case larger:
-			return t.Date.After(*date)
-		case smaller:
-			return t.Date.Before(*date)
-		default:
-			panic(fmt.Sprintf("unexpected search keyword operator: %s", op))
-		}
-	}
-}
-
-func parseDateKeyword(kw string) (matchOp, string) {
-	matches := dateRegexp.FindStringSubmatch(kw)
-	if matches == nil {
-		return noneOp, ""
-	} else {
-		return matchOp(matches[1]), matches[2]
-	}
-}
-
-func parseDate(dateStr string) *time.Time {
-	for _, f := range dateFormats {
-		if d, err := time.Parse(f, dateStr); err == nil {
-			return &d
-		}
-	}
-	return nil
+// Return a search query matching the transactions of the account, and not of accounts containing its name
+func AccountQuery(account string) string {
+	return kwPrefixAccount + exactKeywordPrefix + quoteKeyword(account)
 }
 
-func (t *Transaction) matchesType(kw string) bool {
-	return strings.Contains(strings.ToLower(string(t.Type)), kw)
+// Return a search query matching the transactions in the category or its descendants, e.g. Food and Food:Groceries but not Seafood
+func CategoryQuery(category string) string {
+	return kwPrefixCategory + quoteKeyword("/^"+regexp.QuoteMeta(category)+"("+CategorySeparator+"|$)/")
 }
 
-func (t *Transaction) matchesAccount(kw string) bool {
-	return strings.Contains(strings.ToLower(t.Account), kw)
+// Return a search query matching the transactions with the tag
+func TagQuery(tag string) string {
+	return kwPrefixTag + quoteKeyword(tag)
 }
 
-func (t *Transaction) matchesCategory(kw string) bool {
-	return strings.Contains(strings.ToLower(t.Category), kw)
+func quoteKeyword(kw string) string {
+	return `"` + strings.ReplaceAll(kw, `"`, "") + `"`
 }
 
-func (t *Transaction) matchesAmount(kw string) bool {
-	op, num := parseAmountKeyword(kw)
-	if op == noneOp {
-		// No operator, just string matching
-		if num != "" {
-			return strings.Contains(fmt.Sprintf("%.2f", t.Amount), num)
-		} else {
-			return false
+// ParseTagList reads a list of tags separated by commas or semicolons, e.g. "vacation, project:kitchen"
+// Return the tags and the values of tags with a value
+func ParseTagList(s string) ([]string, map[string]string) {
+	tags := []string{}
+	metadata := map[string]string{}
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		tag, value, _ := strings.Cut(item, ":")
+		if tag, value = strings.TrimSpace(tag), strings.TrimSpace(value); tag == "" {
+			continue
+		} else if value != "" {
+			metadata[tag] = value
+		} else if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
 		}
-	} else {
-		// Value comparison using the operator
-		// Ignoring the error since format is ensured by regexp match
-		targetAmount, _ := strconv.ParseFloat(num, 64)
-		switch op {
-		case larger:
-			return t.Amount > targetAmount
-		case smaller:
-			return t.Amount < targetAmount
-		default:
-			panic(fmt.Sprintf("unexpected search keyword operator: %s", op))
-		}
-	}
-}
-
-func parseAmountKeyword(kw string) (matchOp, string) {
-	matches := amountRegexp.FindStringSubmatch(kw)
-	if matches == nil {
-		return noneOp, "" // no match at all
-	} else {
-		return matchOp(matches[1]), matches[2]
 	}
-}
-
-func (t *Transaction) matchesDescription(kw string) bool {
-	return strings.Contains(strings.ToLower(t.Description), kw)
+	return tags, metadata
 }
@@ -0,0 +1,86 @@
+package data
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+var (
+	watchFlag         bool
+	watchIntervalFlag time.Duration
+)
+
+func init() {
+	pflag.BoolVar(&watchFlag, "watch", false, "Reload data when the source files change")
+	pflag.DurationVar(&watchIntervalFlag, "watch-interval", 2*time.Second, "How often to check the source files for changes with --watch")
+}
+
+// Whether data is reloaded when the source files change, set by --watch
+func WatchEnabled() bool {
+	return watchFlag
+}
+
+// Return how often files are checked for changes, set by --watch-interval
+func WatchInterval() time.Duration {
+	return max(watchIntervalFlag, 100*time.Millisecond)
+}
+
+// FileSource is implemented by data sources that read files, to reload them when the files change
+type FileSource interface {
+	// Files and glob patterns read by the last load, including included files
+	// Patterns are matched again when checking for changes, so that new files are picked up
+	WatchedFiles() []string
+}
+
+// DataFileSource is implemented by file sources that also watch files without transactions, e.g. the CSV config
+type DataFileSource interface {
+	// Files and glob patterns of the transactions read by the last load
+	DataFiles() []string
+}
+
+type fileStat struct {
+	modTime int64
+	size    int64
+}
+
+// WatchedFiles is the state of the files matching a set of patterns at one point in time
+type WatchedFiles struct {
+	patterns []string
+	stats    map[string]fileStat
+}
+
+// Record the modification time and size of the files matching the patterns
+func NewWatchedFiles(patterns []string) *WatchedFiles {
+	return &WatchedFiles{
+		patterns: patterns,
+		stats:    statFiles(patterns),
+	}
+}
+
+// Record the state of the files matching the same patterns again
+func (w *WatchedFiles) Refresh() *WatchedFiles {
+	return NewWatchedFiles(w.patterns)
+}
+
+// Whether files were added, removed or modified since the state was recorded
+func (w *WatchedFiles) Changed() bool {
+	return !maps.Equal(w.stats, statFiles(w.patterns))
+}
+
+func statFiles(patterns []string) map[string]fileStat {
+	stats := map[string]fileStat{}
+	for _, pattern := range patterns {
+		// Invalid patterns are reported by the data source when loading
+		matches, _ := filepath.Glob(pattern)
+		for _, path := range matches {
+			if info, err := os.Stat(path); err == nil {
+				stats[path] = fileStat{modTime: info.ModTime().UnixNano(), size: info.Size()}
+			}
+		}
+	}
+	return stats
+}
@@ -2,6 +2,9 @@ package date
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,6 +22,73 @@ func (inc Increment) String() string {
 	return string(inc)
 }
 
+// Parse an increment name case-insensitively, e.g. weekly, monthly, quarterly, yearly (or annually) and all
+func ParseIncrement(s string) (Increment, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "weekly", "week":
+		return Weekly, nil
+	case "monthly", "month":
+		return Monthly, nil
+	case "quarterly", "quarter":
+		return Quarterly, nil
+	case "yearly", "year", "annually":
+		return Annually, nil
+	case "all", "all time":
+		return AllTime, nil
+	default:
+		return "", fmt.Errorf("invalid date increment %q", s)
+	}
+}
+
+var (
+	quarterPeriodRegex = regexp.MustCompile(`^(\d{4})-Q([1-4])$`)
+	weekPeriodRegex    = regexp.MustCompile(`^(\d{4})-W(\d{1,2})$`)
+)
+
+// Parse a period, e.g. 2025, 2025-Q1, 2025-03 or 2025-W05, return its first day and increment
+func ParsePeriod(s string) (time.Time, Increment, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if matches := quarterPeriodRegex.FindStringSubmatch(s); matches != nil {
+		year, _ := strconv.Atoi(matches[1])
+		quarter, _ := strconv.Atoi(matches[2])
+		return time.Date(year, time.Month(quarter*3-2), 1, 0, 0, 0, 0, time.Local), Quarterly, nil
+	}
+	if matches := weekPeriodRegex.FindStringSubmatch(s); matches != nil {
+		year, _ := strconv.Atoi(matches[1])
+		week, _ := strconv.Atoi(matches[2])
+		if week < 1 || week > 53 {
+			return time.Time{}, "", fmt.Errorf("invalid week in period %q", s)
+		}
+		// Jan 4th is always in week 1
+		week1 := firstDayOfWeek(time.Date(year, time.January, 4, 0, 0, 0, 0, time.Local))
+		return week1.AddDate(0, 0, (week-1)*7), Weekly, nil
+	}
+	if d, err := time.ParseInLocation("2006-01", s, time.Local); err == nil {
+		return d, Monthly, nil
+	}
+	if d, err := time.ParseInLocation("2006", s, time.Local); err == nil {
+		return d, Annually, nil
+	}
+	return time.Time{}, "", fmt.Errorf("invalid period %q, expecting e.g. 2025, 2025-Q1, 2025-03 or 2025-W05", s)
+}
+
+// Return the name of the period of the increment that contains the date, the reverse of ParsePeriod
+func (inc Increment) PeriodName(date time.Time) string {
+	switch inc {
+	case Weekly:
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case Monthly:
+		return date.Format("2006-01")
+	case Quarterly:
+		return fmt.Sprintf("%d-Q%d", date.Year(), QuarterOfYear(date))
+	case Annually, AllTime:
+		return date.Format("2006")
+	default:
+		panic(fmt.Sprintf("unexpected date increment: %s", inc))
+	}
+}
+
 // Give a date, return the first day of the increment. For example,
 // Monthly.FirstDayInIncrement(2025-04-15) => 2025-04-01
 // Annually.FirstDayInIncrement(2025-04-15) => 2025-01-01
@@ -67,6 +137,22 @@ func (inc Increment) SubtractIncrement(date time.Time) time.Time {
 	}
 }
 
+// Return the length of the increment in months, a week is 12/52 of a month
+func (inc Increment) Months() float64 {
+	switch inc {
+	case Weekly:
+		return 12.0 / 52
+	case Monthly:
+		return 1
+	case Quarterly:
+		return 3
+	case Annually:
+		return 12
+	default:
+		panic(fmt.Sprintf("unexpected date increment: %s", inc))
+	}
+}
+
 func QuarterOfYear(date time.Time) int {
 	return (int(date.Month())-1)/3 + 1
 }
@@ -104,3 +190,9 @@ func firstDayOfQuarter(date time.Time) time.Time {
 func firstDayOfYear(date time.Time) time.Time {
 	return time.Date(date.Year(), 1, 1, 0, 0, 0, 0, date.Location())
 }
+
+// Return the same date and time of day in the local time zone
+// Data sources parse dates in different time zones, which must agree to compare dates across data sources
+func ToLocal(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), date.Hour(), date.Minute(), date.Second(), date.Nanosecond(), time.Local)
+}
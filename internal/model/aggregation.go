@@ -8,33 +8,52 @@ import (
 	"time"
 )
 
-// Return if the Transaction matches the aggregation requirements
-type matchFunc func(*data.Transaction) bool
+// Return the splits of the Transaction that match the aggregation requirements
+type splitsFunc func(*data.Transaction) []data.Split
 
-func aggregateByAccount(transactions []*data.Transaction, aggLevel date.Increment, accountName string) []*ui.TsChartEntry {
+// Return income and expense of the account for each date increment, or of all accounts with ui.AccountNameTotal
+func AggregateByAccount(transactions []*data.Transaction, aggLevel date.Increment, accountName string) []*ui.TsChartEntry {
 	return aggregate(
 		transactions,
 		aggLevel,
-		func(t *data.Transaction) bool {
-			return accountName == ui.AccountNameTotal || t.Account == accountName
+		func(t *data.Transaction) []data.Split {
+			if accountName == ui.AccountNameTotal || t.Account == accountName {
+				return t.CategorySplits()
+			}
+			return nil
 		})
 }
 
-func aggregateByCategory(transactions []*data.Transaction, aggLevel date.Increment, categoryName string) []*ui.TsChartEntry {
+// Return income and expense of the category for each date increment
+func AggregateByCategory(transactions []*data.Transaction, aggLevel date.Increment, categoryName string) []*ui.TsChartEntry {
 	return aggregate(
 		transactions,
 		aggLevel,
-		func(t *data.Transaction) bool {
-			return t.Category == categoryName
+		func(t *data.Transaction) []data.Split {
+			return t.SplitsInCategory(categoryName)
 		})
 }
 
-func aggregate(transactions []*data.Transaction, aggLevel date.Increment, matches matchFunc) []*ui.TsChartEntry {
+// Return income and expense of the transactions with the tag for each date increment
+func AggregateByTag(transactions []*data.Transaction, aggLevel date.Increment, tag string) []*ui.TsChartEntry {
+	return aggregate(
+		transactions,
+		aggLevel,
+		func(t *data.Transaction) []data.Split {
+			if t.HasTag(tag) {
+				return t.CategorySplits()
+			}
+			return nil
+		})
+}
+
+func aggregate(transactions []*data.Transaction, aggLevel date.Increment, matchingSplits splitsFunc) []*ui.TsChartEntry {
 	// Store aggregated results in a map for easier access by date
 	// It's critical to use pointers to update entries
 	entryMap := make(map[time.Time]*ui.TsChartEntry)
 	for _, t := range transactions {
-		if !matches(t) {
+		splits := matchingSplits(t)
+		if len(splits) == 0 {
 			continue
 		}
 		// Aggregate results by date increment
@@ -44,10 +63,13 @@ func aggregate(transactions []*data.Transaction, aggLevel date.Increment, matche
 			entry = &ui.TsChartEntry{Date: date}
 			entryMap[date] = entry
 		}
-		if t.Type == data.Income {
-			entry.Income += t.Amount
-		} else {
-			entry.Expense += t.Amount
+		for _, s := range splits {
+			switch s.Type {
+			case data.Income:
+				entry.Income += s.Amount
+			case data.Expense:
+				entry.Expense += s.Amount
+			}
 		}
 	}
 	// Convert aggregated results to an array and sort by date
@@ -60,3 +82,20 @@ func aggregate(transactions []*data.Transaction, aggLevel date.Increment, matche
 	})
 	return entries
 }
+
+// Return assets and liabilities at the end of each date increment from the first to the last transaction
+func aggregateNetWorth(balances *data.BalanceHistory, transactions []*data.Transaction, aggLevel date.Increment) []*ui.TsChartEntry {
+	entries := []*ui.TsChartEntry{}
+	if len(transactions) == 0 {
+		return entries
+	}
+	if aggLevel == date.AllTime {
+		aggLevel = date.Annually
+	}
+	lastDate := transactions[len(transactions)-1].Date
+	for d := aggLevel.FirstDayInIncrement(transactions[0].Date); !d.After(lastDate); d = aggLevel.AddIncrement(d) {
+		assets, liabilities := balances.NetWorthBefore(aggLevel.AddIncrement(d))
+		entries = append(entries, &ui.TsChartEntry{Date: d, Assets: assets, Liabilities: liabilities})
+	}
+	return entries
+}
@@ -0,0 +1,253 @@
+package model
+
+import (
+	"cashd/internal/data"
+	"cashd/internal/data/beancount"
+	"cashd/internal/data/csv"
+	"cashd/internal/data/ledger"
+	"cashd/internal/data/ofx"
+	"cashd/internal/data/qif"
+	"cashd/internal/date"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// LoadedData is everything cashd shows, in the reporting currency
+type LoadedData struct {
+	// Sorted by date
+	Transactions []*data.Transaction
+	Balances     *data.BalanceHistory
+	// Budgets by category
+	Budgets map[string]data.Budget
+	// Records skipped by the data sources, only collected with the collect parse error policy
+	Problems []*data.ParseError
+	// Possible duplicates left for the user to review, both transactions of each pair are in Transactions
+	Duplicates []*data.DuplicatePair
+	// State of the files read, only set with --watch
+	WatchedFiles *data.WatchedFiles
+	// Data sources that can write edited transactions back, by the source name of their transactions
+	Editors map[string]data.Editor
+	// Where transactions added in the TUI go, nil if there is nowhere to add them
+	Appender   data.Appender
+	AppendFile string
+	// Currency of the amounts, to set with data.SetReportingCurrency when the data is shown
+	ReportingCurrency string
+}
+
+// LoadData loads transactions from all enabled data sources, then converts them to the reporting currency
+// Preferred data sources come first, so they are the default place for added transactions
+func LoadData() (*LoadedData, error) {
+	datasources := []data.DataSource{
+		&ledger.LedgerDataSource{},
+		&csv.CsvDataSource{},
+		&ofx.OfxDataSource{},
+		&qif.QifDataSource{},
+		&beancount.BeancountDataSource{},
+	}
+	selected := []data.DataSource{}
+	for _, ds := range datasources {
+		if ds.Preferred() {
+			selected = append(selected, ds)
+		}
+	}
+	for _, ds := range datasources {
+		if ds.Enabled() && !ds.Preferred() {
+			selected = append(selected, ds)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("No available data source")
+	}
+	loaded, err := loadDataFromDataSources(selected)
+	if err != nil {
+		return nil, err
+	}
+	loaded.Appender, loaded.AppendFile, err = appendTarget(selected)
+	if err != nil {
+		return nil, err
+	}
+	return loaded, nil
+}
+
+// Return the data source that appends transactions added in the TUI and the file it appends to
+// The file is --add-to, which must be one of the loaded files, or the default file of the first data source that can append
+func appendTarget(datasources []data.DataSource) (data.Appender, string, error) {
+	if file := data.AddToFile(); file != "" {
+		for _, ds := range datasources {
+			if fs, ok := ds.(data.FileSource); !ok || !readsFile(fs, file) {
+				continue
+			}
+			if a, ok := ds.(data.Appender); ok {
+				return a, file, nil
+			}
+			return nil, "", fmt.Errorf("--add-to must be a ledger journal or a CSV file: %s", file)
+		}
+		return nil, "", fmt.Errorf("--add-to must be one of the loaded files: %s", file)
+	}
+	for _, ds := range datasources {
+		if a, ok := ds.(data.Appender); ok {
+			if file := a.DefaultAppendFile(); file != "" {
+				return a, file, nil
+			}
+		}
+	}
+	return nil, "", nil
+}
+
+// Whether the file is one of the files or glob patterns of transactions read by the data source
+func readsFile(fs data.FileSource, file string) bool {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return false
+	}
+	patterns := fs.WatchedFiles()
+	if dfs, ok := fs.(data.DataFileSource); ok {
+		// Not e.g. the CSV config
+		patterns = dfs.DataFiles()
+	}
+	for _, pattern := range patterns {
+		pattern, err := filepath.Abs(pattern)
+		if err != nil {
+			continue
+		}
+		if matched, _ := filepath.Match(pattern, path); matched || pattern == path {
+			return true
+		}
+	}
+	return false
+}
+
+func loadDataFromDataSources(datasources []data.DataSource) (*LoadedData, error) {
+	// Data sources are independent of each other, so they are loaded concurrently
+	results := make([][]*data.Transaction, len(datasources))
+	errs := make([]error, len(datasources))
+	var wg sync.WaitGroup
+	for i, ds := range datasources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = ds.LoadTransactions()
+		}()
+	}
+	wg.Wait()
+
+	prices, err := data.LoadPriceFile()
+	if err != nil {
+		return nil, err
+	}
+	transactions := []*data.Transaction{}
+	var balances []data.Balance
+	var problems []*data.ParseError
+	watched := []string{}
+	editors := map[string]data.Editor{}
+	for i, ds := range datasources {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("failed to load %s data: %w", ds.Name(), errs[i])
+		}
+		for _, t := range results[i] {
+			t.Source = ds.Name()
+			t.Date = date.ToLocal(t.Date)
+		}
+		transactions = append(transactions, results[i]...)
+
+		if ps, ok := ds.(data.PriceSource); ok {
+			for _, p := range ps.Prices() {
+				p.Date = date.ToLocal(p.Date)
+				prices = append(prices, p)
+			}
+		}
+		if bs, ok := ds.(data.BalanceSource); ok {
+			for _, b := range bs.Balances() {
+				b.Date = date.ToLocal(b.Date)
+				balances = append(balances, b)
+			}
+		}
+		if ps, ok := ds.(data.ProblemSource); ok {
+			problems = append(problems, ps.Problems()...)
+		}
+		if fs, ok := ds.(data.FileSource); ok {
+			watched = append(watched, fs.WatchedFiles()...)
+		}
+		if e, ok := ds.(data.Editor); ok {
+			editors[ds.Name()] = e
+		}
+	}
+	var watchedFiles *data.WatchedFiles
+	if data.WatchEnabled() {
+		// Changes made while loading are only picked up by the next change
+		watchedFiles = data.NewWatchedFiles(watched)
+	}
+	// Rules run before duplicates are found, so that duplicates can be told apart by the fields rules set
+	rules, err := data.LoadRules()
+	if err != nil {
+		return nil, err
+	}
+	data.ApplyRules(transactions, rules)
+
+	// Each data source is sorted by date, the merged transactions are not
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].Date.Before(transactions[j].Date)
+	})
+	// Duplicates are found before conversion, which can round amounts differently
+	transactions, duplicates, err := resolveDuplicates(transactions)
+	if err != nil {
+		return nil, err
+	}
+	transactions, balances, unconverted, reportingCurrency := data.ConvertCurrency(transactions, balances, prices)
+	problems = append(problems, unconverted...)
+
+	budgets, err := data.LoadBudgets()
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoadedData{
+		Transactions:      transactions,
+		Balances:          data.NewBalanceHistory(transactions, balances),
+		Budgets:           budgets,
+		Problems:          problems,
+		Duplicates:        duplicates,
+		WatchedFiles:      watchedFiles,
+		Editors:           editors,
+		ReportingCurrency: reportingCurrency,
+	}, nil
+}
+
+// Drop duplicates following the duplicate policy and the decisions of the user
+// Return the remaining transactions and the pairs left to review
+func resolveDuplicates(transactions []*data.Transaction) ([]*data.Transaction, []*data.DuplicatePair, error) {
+	policy, err := data.GetDuplicatePolicy()
+	if err != nil {
+		return nil, nil, err
+	}
+	if policy == data.IgnoreDuplicates {
+		return transactions, nil, nil
+	}
+	decisions, err := data.LoadDuplicateDecisions()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dropped := []*data.DuplicatePair{}
+	review := []*data.DuplicatePair{}
+	for _, pair := range data.FindDuplicates(transactions) {
+		switch decisions[pair.Key] {
+		case data.KeepBoth:
+		case data.DropDuplicate:
+			dropped = append(dropped, pair)
+		default:
+			if policy == data.MergeDuplicates {
+				dropped = append(dropped, pair)
+			} else {
+				review = append(review, pair)
+			}
+		}
+	}
+	if len(dropped) > 0 {
+		log.Printf("Dropped %d duplicate transactions\n", len(dropped))
+	}
+	return data.RemoveDuplicates(transactions, dropped), review, nil
+}
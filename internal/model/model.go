@@ -2,13 +2,11 @@ package model
 
 import (
 	"cashd/internal/data"
-	"cashd/internal/data/csv"
-	"cashd/internal/data/ledger"
 	"cashd/internal/date"
 	"cashd/internal/ui"
 	"fmt"
+	"log"
 	"sort"
-	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -16,16 +14,48 @@ import (
 )
 
 type dataLoadingSuccessMsg struct {
-	transactions []*data.Transaction
+	loaded *LoadedData
 }
 
 type dataLoadingErrorMsg struct {
 	err error
 }
 
+// Result of checking the watched files for changes
+type filesCheckedMsg struct {
+	watched *data.WatchedFiles
+	changed bool
+}
+
+// Result of writing an edited transaction back to its file
+type transactionSavedMsg struct {
+	err error
+}
+
+// Result of appending a new transaction to the add target
+type transactionAddedMsg struct {
+	err error
+}
+
+// Result of a bulk action, with the transactions it failed for
+type bulkActionDoneMsg struct {
+	changed int
+	failed  []*data.Transaction
+	err     error
+}
+
 type Model struct {
 	allTransactions  []*data.Transaction
 	viewTransactions []*data.Transaction
+	balances         *data.BalanceHistory
+	budgets          map[string]data.Budget
+	// Files to reload the data from when they change, nil unless watching
+	watched *data.WatchedFiles
+	// Data sources that can write edited transactions back, by source name
+	editors map[string]data.Editor
+	// Where new transactions are added, nil if there is nowhere to add them
+	appender   data.Appender
+	appendFile string
 
 	errMsg string
 
@@ -41,12 +71,28 @@ type Model struct {
 	categoryTable    ui.SortableTableModel
 	categoryInsights ui.InsightsModel
 	categoryChart    ui.TimeSeriesChartModel
+	tagTable         ui.SortableTableModel
+	tagInsights      ui.InsightsModel
+	tagChart         ui.TimeSeriesChartModel
+	netWorthChart    ui.TimeSeriesChartModel
+	problems         ui.ProblemsModel
+	duplicates       ui.DuplicatesModel
+	details          ui.DetailsModel
+	editForm         ui.EditFormModel
+	addForm          ui.AddFormModel
+	bulkActions      ui.BulkActionsModel
 	help             ui.HelpModel
 
-	globalQuit     key.Binding
-	activateSearch key.Binding
-	clearSearch    key.Binding
-	toggleHelp     key.Binding
+	globalQuit       key.Binding
+	activateSearch   key.Binding
+	clearSearch      key.Binding
+	toggleHelp       key.Binding
+	toggleProblems   key.Binding
+	toggleDuplicates key.Binding
+	enter            key.Binding
+	edit             key.Binding
+	add              key.Binding
+	bulk             key.Binding
 
 	width  int
 	height int
@@ -54,6 +100,8 @@ type Model struct {
 
 func NewModel() Model {
 	return Model{
+		balances:         data.NewBalanceHistory(nil, nil),
+		budgets:          map[string]data.Budget{},
 		loadingScreen:    ui.NewLoadingScreenModel(),
 		transactionTable: ui.NewTransactionTableModel(),
 		datePicker:       ui.NewDatePickerModel(),
@@ -66,12 +114,28 @@ func NewModel() Model {
 		categoryTable:    ui.NewCategoryTableModel(),
 		categoryInsights: ui.NewInsightsModel(),
 		categoryChart:    ui.NewTimeSeriesChartModel(),
+		tagTable:         ui.NewTagTableModel(),
+		tagInsights:      ui.NewInsightsModel(),
+		tagChart:         ui.NewTimeSeriesChartModel(),
+		netWorthChart:    ui.NewNetWorthChartModel(),
+		problems:         ui.NewProblemsModel(),
+		duplicates:       ui.NewDuplicatesModel(),
+		details:          ui.NewDetailsModel(),
+		editForm:         ui.NewEditFormModel(),
+		addForm:          ui.NewAddFormModel(),
+		bulkActions:      ui.NewBulkActionsModel(),
 		help:             ui.NewHelpModel(),
 
-		globalQuit:     key.NewBinding(key.WithKeys("ctrl+c")),
-		activateSearch: key.NewBinding(key.WithKeys("/")),
-		clearSearch:    key.NewBinding(key.WithKeys("esc")),
-		toggleHelp:     key.NewBinding(key.WithKeys("?")),
+		globalQuit:       key.NewBinding(key.WithKeys("ctrl+c")),
+		activateSearch:   key.NewBinding(key.WithKeys("/")),
+		clearSearch:      key.NewBinding(key.WithKeys("esc")),
+		toggleHelp:       key.NewBinding(key.WithKeys("?")),
+		toggleProblems:   key.NewBinding(key.WithKeys("!")),
+		toggleDuplicates: key.NewBinding(key.WithKeys("D")),
+		enter:            key.NewBinding(key.WithKeys("enter")),
+		edit:             key.NewBinding(key.WithKeys("e")),
+		add:              key.NewBinding(key.WithKeys("n")),
+		bulk:             key.NewBinding(key.WithKeys("b")),
 	}
 }
 
@@ -91,6 +155,40 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		} else if m.searchInput.Focused() {
 			return m, m.processSearchInputKeys(msg)
+		} else if key.Matches(msg, m.toggleProblems) || (m.problems.Visible() && key.Matches(msg, m.clearSearch)) {
+			m.problems.ToggleVisibility()
+			return m, nil
+		} else if m.problems.Visible() {
+			// The problems panel covers the view and takes all other keys
+			m.problems, cmd = m.problems.Update(msg)
+			return m, cmd
+		} else if key.Matches(msg, m.toggleDuplicates) || (m.duplicates.Visible() && key.Matches(msg, m.clearSearch)) {
+			m.duplicates.ToggleVisibility()
+			return m, nil
+		} else if m.duplicates.Visible() {
+			// Like the problems panel, the duplicates panel takes all other keys
+			m.duplicates, cmd = m.duplicates.Update(msg)
+			m.navBar.SetDuplicateCount(m.duplicates.Count())
+			return m, cmd
+		} else if m.editForm.Visible() {
+			// The edit form takes all keys, it closes itself when saved or cancelled
+			m.editForm, cmd = m.editForm.Update(msg)
+			return m, cmd
+		} else if m.addForm.Visible() {
+			// Like the edit form, the add form takes all keys
+			m.addForm, cmd = m.addForm.Update(msg)
+			return m, cmd
+		} else if m.bulkActions.Visible() {
+			m.bulkActions, cmd = m.bulkActions.Update(msg)
+			return m, cmd
+		} else if m.details.Visible() {
+			// The details panel closes with the key that opens it, and scrolls with other keys
+			if key.Matches(msg, m.clearSearch, m.enter) {
+				m.details.Hide()
+			} else {
+				m.details, cmd = m.details.Update(msg)
+			}
+			return m, cmd
 		}
 
 		// Send key to the active view
@@ -101,6 +199,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, m.processAccountViewKeys(msg))
 		case ui.CategoryView:
 			cmds = append(cmds, m.processCategoryViewKeys(msg))
+		case ui.TagView:
+			cmds = append(cmds, m.processTagViewKeys(msg))
+		case ui.NetWorthView:
+			m.processNetWorthViewKeys(msg)
 		}
 		// Global components always process key events
 		m.datePicker, cmd = m.datePicker.Update(msg)
@@ -110,24 +212,63 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case dataLoadingSuccessMsg:
 		cmds = append(cmds, m.loadingScreen.Stop())
-		m.allTransactions = msg.transactions
+		data.SetReportingCurrency(msg.loaded.ReportingCurrency)
+		m.allTransactions = msg.loaded.Transactions
+		m.balances = msg.loaded.Balances
+		m.budgets = msg.loaded.Budgets
+		m.editors = msg.loaded.Editors
+		m.appender, m.appendFile = msg.loaded.Appender, msg.loaded.AppendFile
+		m.navBar.SetReloadFailed(false)
+		m.problems.SetProblems(msg.loaded.Problems)
+		m.navBar.SetProblemCount(m.problems.Count())
+		m.duplicates.SetPairs(msg.loaded.Duplicates)
+		m.navBar.SetDuplicateCount(m.duplicates.Count())
 		m.updateDatePickerLimits()
 		cmds = append(cmds, m.filterTransactions())
 		m.onSelectedAccountChanged()
 		m.onSelectedCategoryChanged()
+		m.onSelectedTagChanged()
+		m.updateNetWorthChart()
+		if m.watched = msg.loaded.WatchedFiles; m.watched != nil {
+			cmds = append(cmds, watchFiles(m.watched))
+		}
 
 	case dataLoadingErrorMsg:
+		if !m.loadingScreen.IsLoading() {
+			// A failed reload keeps the data loaded before, e.g. while a file is being edited
+			log.Printf("Failed to reload data: %v\n", msg.err)
+			m.navBar.SetReloadFailed(true)
+			if m.watched != nil {
+				m.watched = m.watched.Refresh()
+				cmds = append(cmds, watchFiles(m.watched))
+			}
+			break
+		}
 		cmds = append(cmds, m.loadingScreen.Stop())
 		m.errMsg = msg.err.Error()
 
+	case filesCheckedMsg:
+		if msg.watched != m.watched {
+			// Files checked before the last load
+			break
+		}
+		if msg.changed {
+			cmds = append(cmds, loadTransactions())
+		} else {
+			cmds = append(cmds, watchFiles(m.watched))
+		}
+
 	case ui.DateRangeChangedMsg:
 		cmds = append(cmds, m.filterTransactions())
 		m.updateAccountInsights()
 		m.updateCategoryInsights()
+		m.updateTagInsights()
 
 	case ui.DateIncrementChangedMsg:
 		m.onSelectedAccountChanged()
 		m.onSelectedCategoryChanged()
+		m.onSelectedTagChanged()
+		m.updateNetWorthChart()
 
 	case ui.TableSelectionChangedMsg:
 		switch msg.TableName {
@@ -135,7 +276,57 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.onSelectedAccountChanged()
 		case ui.CategoryTableName:
 			m.onSelectedCategoryChanged()
+		case ui.TagTableName:
+			m.onSelectedTagChanged()
+		}
+
+	case ui.TransactionEditMsg:
+		cmds = append(cmds, m.saveTransaction(msg.Transaction, msg.Edit))
+
+	case transactionSavedMsg:
+		if msg.err != nil {
+			log.Printf("Failed to save transaction: %v\n", msg.err)
+			m.editForm.SetError(msg.err)
+			break
+		}
+		// Reload so that rules, totals and balances see the edited transaction
+		m.editForm.Hide()
+		cmds = append(cmds, loadTransactions())
+
+	case ui.TableMarksChangedMsg:
+		m.summary.SetMarked(m.markedTransactions())
+
+	case ui.BulkActionMsg:
+		cmds = append(cmds, m.runBulkAction(msg))
+
+	case bulkActionDoneMsg:
+		if msg.err != nil {
+			log.Printf("Bulk action failed: %v\n", msg.err)
+			m.bulkActions.SetFailed(msg.failed, msg.err)
+		} else {
+			m.bulkActions.Hide()
+			cmds = append(cmds, m.transactionTable.ClearMarks())
+		}
+		if msg.changed > 0 {
+			// Reloaded transactions are new rows, so the marks are dropped
+			cmds = append(cmds, loadTransactions())
+		}
+
+	case ui.TransactionAddMsg:
+		cmds = append(cmds, m.addTransaction(msg.Transaction))
+
+	case transactionAddedMsg:
+		if msg.err != nil {
+			log.Printf("Failed to add transaction: %v\n", msg.err)
+			m.addForm.SetError(msg.err)
+			break
 		}
+		m.addForm.Hide()
+		cmds = append(cmds, loadTransactions())
+
+	case ui.DuplicateDroppedMsg:
+		// Reload in the background so that totals and balances no longer count the duplicate
+		cmds = append(cmds, loadTransactions())
 
 	case ui.SearchMsg:
 		cmds = append(cmds, m.updateTransactionTable())
@@ -175,6 +366,18 @@ func (m *Model) processTransactionViewKeys(msg tea.KeyMsg) tea.Cmd {
 		switch {
 		case key.Matches(msg, m.activateSearch):
 			m.searchInput.Focus()
+		case key.Matches(msg, m.enter):
+			if t, ok := m.transactionTable.SelectedItem().(*data.Transaction); ok {
+				m.details.Show(t)
+			}
+		case key.Matches(msg, m.edit):
+			if t, ok := m.transactionTable.SelectedItem().(*data.Transaction); ok {
+				return m.editForm.Show(t)
+			}
+		case key.Matches(msg, m.add):
+			return m.addForm.Show(m.allTransactions, m.appendFile)
+		case key.Matches(msg, m.bulk):
+			m.bulkActions.Show(m.markedTransactions(), m.allTransactions)
 		case key.Matches(msg, m.toggleHelp):
 			m.help.ToggleVisibility()
 			m.updateLayout()
@@ -191,6 +394,12 @@ func (m *Model) processAccountViewKeys(msg tea.KeyMsg) tea.Cmd {
 	case key.Matches(msg, m.toggleHelp):
 		m.help.ToggleVisibility()
 		m.updateLayout()
+	case key.Matches(msg, m.enter):
+		if account := m.accountTable.Selected(); account == ui.AccountNameTotal {
+			return m.drillDown("")
+		} else if account != "" {
+			return m.drillDown(data.AccountQuery(account))
+		}
 	default:
 		var cmd tea.Cmd
 		m.accountTable, cmd = m.accountTable.Update(msg)
@@ -204,6 +413,10 @@ func (m *Model) processCategoryViewKeys(msg tea.KeyMsg) tea.Cmd {
 	case key.Matches(msg, m.toggleHelp):
 		m.help.ToggleVisibility()
 		m.updateLayout()
+	case key.Matches(msg, m.enter):
+		if category := m.categoryTable.Selected(); category != "" {
+			return m.drillDown(data.CategoryQuery(category))
+		}
 	default:
 		var cmd tea.Cmd
 		m.categoryTable, cmd = m.categoryTable.Update(msg)
@@ -212,6 +425,36 @@ func (m *Model) processCategoryViewKeys(msg tea.KeyMsg) tea.Cmd {
 	return nil
 }
 
+func (m *Model) processTagViewKeys(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.toggleHelp):
+		m.help.ToggleVisibility()
+		m.updateLayout()
+	case key.Matches(msg, m.enter):
+		if tag := m.tagTable.Selected(); tag != "" {
+			return m.drillDown(data.TagQuery(tag))
+		}
+	default:
+		var cmd tea.Cmd
+		m.tagTable, cmd = m.tagTable.Update(msg)
+		return cmd
+	}
+	return nil
+}
+
+// Show the transactions matching the query in the transactions view, in the same date range
+func (m *Model) drillDown(query string) tea.Cmd {
+	m.searchInput.Blur()
+	return tea.Batch(m.searchInput.SetValue(query), m.navBar.SetViewMode(ui.TransactionView))
+}
+
+func (m *Model) processNetWorthViewKeys(msg tea.KeyMsg) {
+	if key.Matches(msg, m.toggleHelp) {
+		m.help.ToggleVisibility()
+		m.updateLayout()
+	}
+}
+
 func (m *Model) updateDatePickerLimits() {
 	if txnCount := len(m.allTransactions); txnCount == 0 {
 		return
@@ -244,38 +487,34 @@ func (m *Model) filterTransactions() tea.Cmd {
 	// It's safe to just create a subslice (no copy) because viewTransactions is read-only
 	m.viewTransactions = m.allTransactions[startIndex:endIndex]
 
+	ctx := m.tableContext()
 	return tea.Batch(
 		m.updateTransactionTable(),
-		m.accountTable.SetTransactions(m.viewTransactions),
-		m.categoryTable.SetTransactions(m.viewTransactions),
+		m.accountTable.SetTransactions(m.viewTransactions, ctx),
+		m.categoryTable.SetTransactions(m.viewTransactions, ctx),
+		m.tagTable.SetTransactions(m.viewTransactions, ctx),
 	)
 }
 
+// Return what tables show besides transactions for the selected date range
+func (m *Model) tableContext() ui.TableContext {
+	startDate, endDate := m.datePicker.SelectedDateRange()
+	return ui.NewTableContext(m.balances, m.budgets, m.datePicker.Inc(), startDate, endDate)
+}
+
+// Return the budget of the category for the selected date range, including its descendants, 0 if the category has no budget
+func (m *Model) budgetInDateRange(category string) data.Money {
+	return m.tableContext().Budgets[category]
+}
+
 func (m *Model) updateTransactionTable() tea.Cmd {
 	txns := m.searchTransactions()
 	m.summary.SetTransactions(txns)
-	return m.transactionTable.SetTransactions(txns)
+	return m.transactionTable.SetTransactions(txns, ui.TableContext{})
 }
 
 func (m *Model) searchTransactions() []*data.Transaction {
-	searchQuery := strings.TrimSpace(m.searchInput.Value())
-	if searchQuery == "" {
-		return m.viewTransactions
-	} else {
-		matchingTransactions := []*data.Transaction{}
-		subQueries := strings.Split(searchQuery, " OR ")
-		for _, t := range m.viewTransactions {
-			for _, query := range subQueries {
-				keywords := strings.Fields(strings.ToLower(query))
-				if len(keywords) > 0 && t.Matches(keywords) {
-					// If any of the sub-queries match, the transaction  a match
-					matchingTransactions = append(matchingTransactions, t)
-					break
-				}
-			}
-		}
-		return matchingTransactions
-	}
+	return m.searchInput.Query().Filter(m.viewTransactions)
 }
 
 func (m *Model) onSelectedAccountChanged() {
@@ -283,7 +522,7 @@ func (m *Model) onSelectedAccountChanged() {
 		return
 	}
 
-	entries := aggregateByAccount(m.allTransactions, m.datePicker.Inc(), m.accountTable.Selected())
+	entries := AggregateByAccount(m.allTransactions, m.datePicker.Inc(), m.accountTable.Selected())
 	m.accountChart.SetEntries(
 		getTimeSeriesChartName(m.datePicker.Inc(), m.accountTable.Selected()),
 		entries,
@@ -298,7 +537,21 @@ func (m *Model) onSelectedCategoryChanged() {
 		return
 	}
 
-	entries := aggregateByCategory(m.allTransactions, m.datePicker.Inc(), m.categoryTable.Selected())
+	entries := AggregateByCategory(m.allTransactions, m.datePicker.Inc(), m.categoryTable.Selected())
+	// The chart shows all time in years
+	inc := m.datePicker.Inc()
+	if inc == date.AllTime {
+		inc = date.Annually
+	}
+	scaled := map[string]data.Money{}
+	for category, budget := range m.budgets {
+		scaled[category] = budget.Scaled(inc.Months())
+	}
+	if budget, exist := data.RollUpBudgets(scaled)[m.categoryTable.Selected()]; exist {
+		for _, e := range entries {
+			e.Budget = budget
+		}
+	}
 	m.categoryChart.SetEntries(
 		getTimeSeriesChartName(m.datePicker.Inc(), m.categoryTable.Selected()),
 		entries,
@@ -308,6 +561,29 @@ func (m *Model) onSelectedCategoryChanged() {
 	m.updateCategoryInsights()
 }
 
+func (m *Model) onSelectedTagChanged() {
+	if m.tagTable.Selected() == "" {
+		return
+	}
+
+	entries := AggregateByTag(m.allTransactions, m.datePicker.Inc(), m.tagTable.Selected())
+	m.tagChart.SetEntries(
+		getTimeSeriesChartName(m.datePicker.Inc(), m.tagTable.Selected()),
+		entries,
+		m.datePicker.Inc(),
+	)
+
+	m.updateTagInsights()
+}
+
+func (m *Model) updateNetWorthChart() {
+	m.netWorthChart.SetEntries(
+		getTimeSeriesChartName(m.datePicker.Inc(), "Net Worth"),
+		aggregateNetWorth(m.balances, m.allTransactions, m.datePicker.Inc()),
+		m.datePicker.Inc(),
+	)
+}
+
 func getTimeSeriesChartName(inc date.Increment, name string) string {
 	incStr := string(inc)
 	if inc == date.AllTime {
@@ -325,35 +601,135 @@ func (m *Model) updateAccountInsights() {
 
 func (m *Model) updateCategoryInsights() {
 	m.categoryInsights.SetTransactionsWithCategory(m.viewTransactions, m.categoryTable.Selected())
+	m.categoryInsights.SetBudget(m.budgetInDateRange(m.categoryTable.Selected()))
 	m.categoryInsights.SetName(fmt.Sprintf("%s insights: %s", m.categoryTable.Selected(), m.datePicker.ViewDateRange()))
 
 	m.updateLayout()
 }
 
-func loadTransactions() tea.Cmd {
-	datasources := []data.DataSource{ledger.LedgerDataSource{}, csv.CsvDataSource{}}
-	for _, ds := range datasources {
-		if ds.Preferred() {
-			return loadTransactionsFromDataSource(ds)
+func (m *Model) updateTagInsights() {
+	m.tagInsights.SetTransactionsWithTag(m.viewTransactions, m.tagTable.Selected())
+	m.tagInsights.SetName(fmt.Sprintf("%s insights: %s", m.tagTable.Selected(), m.datePicker.ViewDateRange()))
+
+	m.updateLayout()
+}
+
+// Check the watched files for changes after the watch interval
+func watchFiles(watched *data.WatchedFiles) tea.Cmd {
+	return tea.Tick(data.WatchInterval(), func(time.Time) tea.Msg {
+		return filesCheckedMsg{watched: watched, changed: watched.Changed()}
+	})
+}
+
+// Write the edit back to the file of the transaction with the editor of its data source
+func (m *Model) saveTransaction(t *data.Transaction, edit data.TransactionEdit) tea.Cmd {
+	editors := m.editors
+	return func() tea.Msg {
+		return transactionSavedMsg{editTransaction(editors, t, edit)}
+	}
+}
+
+func editTransaction(editors map[string]data.Editor, t *data.Transaction, edit data.TransactionEdit) error {
+	editor, ok := editors[t.Source]
+	if !ok {
+		return fmt.Errorf("editing %s transactions is not supported", t.Source)
+	}
+	return editor.EditTransaction(t, edit)
+}
+
+func (m *Model) markedTransactions() []*data.Transaction {
+	marked := []*data.Transaction{}
+	for _, item := range m.transactionTable.Marked() {
+		marked = append(marked, item.(*data.Transaction))
+	}
+	return marked
+}
+
+// Export the marked transactions, or edit each of them and collect the ones that fail
+func (m *Model) runBulkAction(msg ui.BulkActionMsg) tea.Cmd {
+	if msg.Action == ui.BulkExport {
+		return func() tea.Msg {
+			return bulkActionDoneMsg{err: ui.ExportTransactions(msg.Value, msg.Transactions)}
 		}
 	}
-	for _, ds := range datasources {
-		if ds.Enabled() {
-			return loadTransactionsFromDataSource(ds)
+	editors := m.editors
+	return func() tea.Msg {
+		done := bulkActionDoneMsg{}
+		var firstErr error
+		// Transactions of the same entry are changed together, the entry is no longer as loaded after the first edit
+		for _, group := range entryGroups(msg.Transactions) {
+			t := group[0]
+			edit := t.EditableFields()
+			switch msg.Action {
+			case ui.BulkRecategorize:
+				edit.Category = msg.Value
+			case ui.BulkChangeAccount:
+				edit.Account = msg.Value
+			case ui.BulkAddTag:
+				edit.Tags = append(edit.Tags, msg.Value)
+			}
+			err := edit.Validate(t)
+			if err == nil && edit.Changes(t) {
+				if err = editTransaction(editors, t, edit); err == nil {
+					done.changed += len(group)
+				}
+			}
+			if err != nil {
+				done.failed = append(done.failed, group...)
+				if firstErr == nil {
+					firstErr = fmt.Errorf("%s %s: %w", t.Date.Format(time.DateOnly), t.Description, err)
+				}
+			}
+		}
+		if len(done.failed) > 0 {
+			done.err = fmt.Errorf("%d of %d transactions were not changed, e.g. %w", len(done.failed), len(msg.Transactions), firstErr)
+		}
+		return done
+	}
+}
+
+// Group the transactions built from the same entry of a file, e.g. an income and a transfer of a journal entry
+// The income or expense of an entry comes first, as it has the category and the account the entry is edited by
+func entryGroups(transactions []*data.Transaction) [][]*data.Transaction {
+	type entry struct {
+		file string
+		line int
+	}
+	groups := [][]*data.Transaction{}
+	index := map[entry]int{}
+	for _, t := range transactions {
+		if t.File == "" || t.Line == 0 {
+			groups = append(groups, []*data.Transaction{t})
+			continue
+		}
+		key := entry{t.File, t.Line}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(groups)
+			groups = append(groups, []*data.Transaction{t})
+		} else if groups[i][0].IsTransfer() && !t.IsTransfer() {
+			groups[i] = append([]*data.Transaction{t}, groups[i]...)
+		} else {
+			groups[i] = append(groups[i], t)
 		}
 	}
+	return groups
+}
+
+// Append the transaction to the add target
+func (m *Model) addTransaction(t *data.Transaction) tea.Cmd {
+	appender, file := m.appender, m.appendFile
 	return func() tea.Msg {
-		return dataLoadingErrorMsg{fmt.Errorf("No available data source")}
+		return transactionAddedMsg{appender.AppendTransaction(t, file)}
 	}
 }
 
-func loadTransactionsFromDataSource(ds data.DataSource) tea.Cmd {
+func loadTransactions() tea.Cmd {
 	return func() tea.Msg {
-		transactions, err := ds.LoadTransactions()
+		loaded, err := LoadData()
 		if err != nil {
 			return dataLoadingErrorMsg{err}
-		} else {
-			return dataLoadingSuccessMsg{transactions}
 		}
+		return dataLoadingSuccessMsg{loaded}
 	}
 }
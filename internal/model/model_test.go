@@ -0,0 +1,68 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cashd/internal/data"
+	"cashd/internal/data/ledger"
+	"cashd/internal/ui"
+
+	"github.com/spf13/pflag"
+)
+
+func TestBulkActionEditsEachEntryOnce(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "books.journal")
+	journal := `2024-01-05 Paycheck
+    assets:401k  $1000
+    assets:Checking  $3000
+    income:Salary  $-4000
+`
+	if err := os.WriteFile(file, []byte(journal), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := pflag.Set("ledger", file); err != nil {
+		t.Fatal(err)
+	}
+	defer pflag.Set("ledger", "")
+
+	source := &ledger.LedgerDataSource{}
+	editors := map[string]data.Editor{source.Name(): source}
+	run := func(action ui.BulkAction, value string) {
+		transactions, err := source.LoadTransactions()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The entry is an income into Checking and a transfer from Checking to the 401k, marked transfer first
+		if len(transactions) != 2 {
+			t.Fatalf("got %d transactions, want an income and a transfer", len(transactions))
+		}
+		for _, tx := range transactions {
+			tx.Source = source.Name()
+		}
+		transactions[0], transactions[1] = transactions[1], transactions[0]
+		m := &Model{editors: editors}
+		done := m.runBulkAction(ui.BulkActionMsg{Action: action, Value: value, Transactions: transactions})().(bulkActionDoneMsg)
+		if done.err != nil || done.changed != 2 {
+			t.Fatalf("%s changed %d transactions, error %v, want 2 changed", action, done.changed, done.err)
+		}
+	}
+
+	run(ui.BulkChangeAccount, "Bank")
+	run(ui.BulkAddTag, "work")
+	run(ui.BulkRecategorize, "Wages")
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Renamed postings keep the amount column
+	want := `2024-01-05 Paycheck  ; :work:
+    assets:401k  $1000
+    assets:Bank      $3000
+    income:Wages   $-4000
+`
+	if string(content) != want {
+		t.Errorf("journal is\n%s\nwant\n%s", content, want)
+	}
+}
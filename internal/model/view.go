@@ -53,6 +53,29 @@ func (m Model) View() string {
 			),
 			m.categoryChart.View(),
 		)
+	case ui.TagView:
+		body = lipgloss.JoinVertical(lipgloss.Left,
+			lipgloss.JoinHorizontal(lipgloss.Top,
+				m.tagTable.View(),
+				m.tagInsights.View(),
+			),
+			m.tagChart.View(),
+		)
+	case ui.NetWorthView:
+		body = m.netWorthChart.View()
+	}
+	if m.problems.Visible() {
+		body = m.problems.View()
+	} else if m.duplicates.Visible() {
+		body = m.duplicates.View()
+	} else if m.editForm.Visible() {
+		body = m.editForm.View()
+	} else if m.addForm.Visible() {
+		body = m.addForm.View()
+	} else if m.bulkActions.Visible() {
+		body = m.bulkActions.View()
+	} else if m.details.Visible() {
+		body = m.details.View()
 	}
 
 	views := []string{top, body}
@@ -88,4 +111,17 @@ func (m *Model) updateLayout() {
 	m.categoryTable.SetDimensions(ui.CategoryTableWidth, insightsHeight)
 	m.categoryInsights.SetDimension(max(30, m.width-ui.CategoryTableWidth-4), insightsHeight)
 	m.categoryChart.SetDimension(m.width-4, bodyHeight-m.categoryInsights.Height()-2)
+	// Tag view components
+	m.tagTable.SetDimensions(ui.TagTableWidth, insightsHeight)
+	m.tagInsights.SetDimension(max(30, m.width-ui.TagTableWidth-4), insightsHeight)
+	m.tagChart.SetDimension(m.width-4, bodyHeight-m.tagInsights.Height()-2)
+	// Net worth view components
+	m.netWorthChart.SetDimension(m.width-4, bodyHeight-2)
+	// Import problems, duplicates, transaction details, the edit and add forms and bulk actions cover the body of any view
+	m.problems.SetDimensions(m.width-4, bodyHeight-2)
+	m.duplicates.SetDimensions(m.width-4, bodyHeight-2)
+	m.details.SetDimensions(m.width-4, bodyHeight-2)
+	m.editForm.SetDimensions(m.width-4, bodyHeight-2)
+	m.addForm.SetDimensions(m.width-4, bodyHeight-2)
+	m.bulkActions.SetDimensions(m.width-4, bodyHeight-2)
 }
@@ -0,0 +1,145 @@
+package report
+
+import (
+	"cashd/internal/data"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Write the report header and rows in an output format
+type writer func(out io.Writer, header []string, rows [][]any) error
+
+func getWriter(format string) (writer, error) {
+	switch strings.ToLower(format) {
+	case "text":
+		return writeText, nil
+	case "csv":
+		return writeCsv, nil
+	case "json":
+		return writeJson, nil
+	default:
+		return nil, fmt.Errorf("invalid report format %q, expecting text, csv or json", format)
+	}
+}
+
+// Plain table with aligned columns, numbers are right aligned
+func writeText(out io.Writer, header []string, rows [][]any) error {
+	widths := make([]int, len(header))
+	rightAligned := make([]bool, len(header))
+	for i, h := range header {
+		widths[i] = len(h)
+	}
+	cells := make([][]string, len(rows))
+	for r, row := range rows {
+		cells[r] = make([]string, len(row))
+		for i, value := range row {
+			switch v := value.(type) {
+			case data.Money:
+				cells[r][i] = v.Format()
+				rightAligned[i] = true
+			case int:
+				cells[r][i] = formatValue(v)
+				rightAligned[i] = true
+			default:
+				cells[r][i] = formatValue(v)
+			}
+			widths[i] = max(widths[i], len([]rune(cells[r][i])))
+		}
+	}
+
+	writeLine := func(line []string) error {
+		padded := make([]string, len(line))
+		for i, cell := range line {
+			if rightAligned[i] {
+				padded[i] = fmt.Sprintf("%*s", widths[i], cell)
+			} else {
+				padded[i] = fmt.Sprintf("%-*s", widths[i], cell)
+			}
+		}
+		_, err := fmt.Fprintln(out, strings.TrimRight(strings.Join(padded, "  "), " "))
+		return err
+	}
+	if err := writeLine(header); err != nil {
+		return err
+	}
+	for _, line := range cells {
+		if err := writeLine(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCsv(out io.Writer, header []string, rows [][]any) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = formatValue(value)
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// An array of objects, one per row, with keys in the order of columns
+func writeJson(out io.Writer, header []string, rows [][]any) error {
+	if _, err := fmt.Fprint(out, "["); err != nil {
+		return err
+	}
+	for r, row := range rows {
+		fields := make([]string, len(row))
+		for i, value := range row {
+			k, err := json.Marshal(header[i])
+			if err != nil {
+				return err
+			}
+			if t, ok := value.(time.Time); ok {
+				value = formatValue(t)
+			}
+			v, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			fields[i] = string(k) + ": " + string(v)
+		}
+		separator := ","
+		if r == len(rows)-1 {
+			separator = ""
+		}
+		if _, err := fmt.Fprintf(out, "\n  {%s}%s", strings.Join(fields, ", "), separator); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(out, "\n]")
+	return err
+}
+
+// Plain representation of a value, without thousands separators
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case data.Money:
+		return v.String()
+	case time.Time:
+		return v.Format(time.DateOnly)
+	default:
+		panic(fmt.Sprintf("unexpected report data type: %v", v))
+	}
+}
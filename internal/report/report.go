@@ -0,0 +1,156 @@
+package report
+
+import (
+	"cashd/internal/data"
+	"cashd/internal/date"
+	"cashd/internal/model"
+	"cashd/internal/ui"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	summaryReport      = "summary"
+	accountsReport     = "accounts"
+	categoriesReport   = "categories"
+	transactionsReport = "transactions"
+	unmatchedReport    = "unmatched"
+	tagsReport         = "tags"
+)
+
+var reports = []string{summaryReport, accountsReport, categoriesReport, tagsReport, transactionsReport, unmatchedReport}
+
+var periodFlag string
+var incFlag string
+var searchFlag string
+var formatFlag string
+
+func init() {
+	pflag.StringVar(&periodFlag, "period", "", "Report period, e.g. 2025, 2025-Q1, 2025-03, 2025-W05, current or previous, defaults to all time")
+	pflag.StringVar(&incFlag, "inc", "monthly", "Report date increment: weekly, monthly, quarterly, yearly or all")
+	pflag.StringVar(&searchFlag, "search", "", "Only report transactions matching the search query")
+	pflag.StringVar(&formatFlag, "format", "text", "Report output format: text, csv or json")
+}
+
+// Run the report named by the first argument and write it to out
+func Run(args []string, out io.Writer) error {
+	if len(args) != 1 || !slices.Contains(reports, args[0]) {
+		return fmt.Errorf("usage: cashd report <%s> [flags]", strings.Join(reports, "|"))
+	}
+	write, err := getWriter(formatFlag)
+	if err != nil {
+		return err
+	}
+	inc, err := date.ParseIncrement(incFlag)
+	if err != nil {
+		return err
+	}
+
+	loaded, err := model.LoadData()
+	if err != nil {
+		return err
+	}
+	data.SetReportingCurrency(loaded.ReportingCurrency)
+	// Problems go to stderr to keep the report parsable
+	for _, p := range loaded.Problems {
+		fmt.Fprintf(os.Stderr, "skipped record: %v\n", p)
+	}
+	if n := len(loaded.Duplicates); n > 0 {
+		fmt.Fprintf(os.Stderr, "%d possible duplicate transactions are counted, review them in the TUI or use --duplicates merge\n", n)
+	}
+	start, end, rangeInc, err := getDateRange(loaded.Transactions, inc, time.Now())
+	if err != nil {
+		return err
+	}
+	transactions, err := data.Search(filterByDate(loaded.Transactions, start, end), searchFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --search: %w", err)
+	}
+
+	var header []string
+	var rows [][]any
+	ctx := ui.NewTableContext(loaded.Balances, loaded.Budgets, rangeInc, start, end)
+	switch args[0] {
+	case summaryReport:
+		header, rows = getSummary(transactions, inc)
+	case accountsReport:
+		header, rows = ui.ReportTable(ui.AccountTableName, transactions, ctx)
+	case categoriesReport:
+		header, rows = ui.ReportTable(ui.CategoryTableName, transactions, ctx)
+	case tagsReport:
+		header, rows = ui.ReportTable(ui.TagTableName, transactions, ctx)
+	case transactionsReport:
+		header, rows = ui.ReportTable(ui.TxnTableName, transactions, ctx)
+	case unmatchedReport:
+		header, rows = ui.ReportTable(ui.TxnTableName, data.Unmatched(transactions), ctx)
+	}
+	return write(out, header, rows)
+}
+
+// Return the start (inclusive) and end (exclusive) of the period set by --period, and the increment of the period
+// Relative periods, i.e. current and previous, are in the unit of inc
+func getDateRange(transactions []*data.Transaction, inc date.Increment, now time.Time) (time.Time, time.Time, date.Increment, error) {
+	switch period := strings.ToLower(strings.TrimSpace(periodFlag)); period {
+	case "":
+		if len(transactions) == 0 {
+			return now, now, date.AllTime, nil
+		}
+		// transactions are sorted by date
+		return transactions[0].Date, transactions[len(transactions)-1].Date.AddDate(0, 0, 1), date.AllTime, nil
+	case "current", "previous":
+		if inc == date.AllTime {
+			return time.Time{}, time.Time{}, "", fmt.Errorf("%s period requires a date increment other than all", period)
+		}
+		start := inc.FirstDayInIncrement(now)
+		if period == "previous" {
+			start = inc.SubtractIncrement(start)
+		}
+		return start, inc.AddIncrement(start), inc, nil
+	default:
+		start, periodInc, err := date.ParsePeriod(period)
+		if err != nil {
+			return time.Time{}, time.Time{}, "", err
+		}
+		return start, periodInc.AddIncrement(start), periodInc, nil
+	}
+}
+
+// Return transactions on or after start and before end, transactions are sorted by date
+func filterByDate(transactions []*data.Transaction, start, end time.Time) []*data.Transaction {
+	startIndex := sort.Search(len(transactions), func(i int) bool {
+		return !transactions[i].Date.Before(start)
+	})
+	endIndex := sort.Search(len(transactions), func(i int) bool {
+		return !transactions[i].Date.Before(end)
+	})
+	return transactions[startIndex:max(startIndex, endIndex)]
+}
+
+// Income, expense and net income of each date increment, followed by the total
+func getSummary(transactions []*data.Transaction, inc date.Increment) ([]string, [][]any) {
+	header := []string{"Period", string(data.Income), string(data.Expense), "Net"}
+	rows := [][]any{}
+
+	// All time is aggregated by year, but only the total is reported
+	aggLevel := inc
+	if inc == date.AllTime {
+		aggLevel = date.Annually
+	}
+	var totalIncome, totalExpense data.Money
+	for _, e := range model.AggregateByAccount(transactions, aggLevel, ui.AccountNameTotal) {
+		totalIncome += e.Income
+		totalExpense += e.Expense
+		if inc != date.AllTime {
+			rows = append(rows, []any{inc.PeriodName(e.Date), e.Income, e.Expense, e.Income - e.Expense})
+		}
+	}
+	rows = append(rows, []any{"Total", totalIncome, totalExpense, totalIncome - totalExpense})
+	return header, rows
+}
@@ -0,0 +1,82 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"cashd/internal/data"
+	"cashd/internal/date"
+)
+
+func reportDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
+
+func TestGetDateRange(t *testing.T) {
+	transactions := []*data.Transaction{
+		{Date: reportDate(2024, 1, 5)},
+		{Date: reportDate(2024, 3, 14)},
+	}
+	now := reportDate(2024, 5, 20)
+	tests := []struct {
+		period    string
+		inc       date.Increment
+		wantStart time.Time
+		wantEnd   time.Time
+		wantInc   date.Increment
+		wantErr   bool
+	}{
+		// All time is the range of the transactions
+		{period: "", inc: date.Monthly, wantStart: reportDate(2024, 1, 5), wantEnd: reportDate(2024, 3, 15), wantInc: date.AllTime},
+		{period: "current", inc: date.Monthly, wantStart: reportDate(2024, 5, 1), wantEnd: reportDate(2024, 6, 1), wantInc: date.Monthly},
+		{period: "Previous", inc: date.Monthly, wantStart: reportDate(2024, 4, 1), wantEnd: reportDate(2024, 5, 1), wantInc: date.Monthly},
+		{period: "previous", inc: date.Quarterly, wantStart: reportDate(2024, 1, 1), wantEnd: reportDate(2024, 4, 1), wantInc: date.Quarterly},
+		{period: "2023", inc: date.Monthly, wantStart: reportDate(2023, 1, 1), wantEnd: reportDate(2024, 1, 1), wantInc: date.Annually},
+		{period: "2024-Q2", inc: date.Monthly, wantStart: reportDate(2024, 4, 1), wantEnd: reportDate(2024, 7, 1), wantInc: date.Quarterly},
+		{period: "current", inc: date.AllTime, wantErr: true},
+		{period: "previous", inc: date.AllTime, wantErr: true},
+		{period: "someday", inc: date.Monthly, wantErr: true},
+	}
+	for _, tt := range tests {
+		periodFlag = tt.period
+		start, end, inc, err := getDateRange(transactions, tt.inc, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("period %q by %s: error = %v, want error %v", tt.period, tt.inc, err, tt.wantErr)
+		} else if !tt.wantErr && (!start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) || inc != tt.wantInc) {
+			t.Errorf("period %q by %s = %s to %s by %s, want %s to %s by %s", tt.period, tt.inc,
+				start.Format(time.DateOnly), end.Format(time.DateOnly), inc,
+				tt.wantStart.Format(time.DateOnly), tt.wantEnd.Format(time.DateOnly), tt.wantInc)
+		}
+	}
+	periodFlag = ""
+}
+
+func TestFilterByDate(t *testing.T) {
+	transactions := []*data.Transaction{
+		{Date: reportDate(2024, 1, 5), Description: "a"},
+		{Date: reportDate(2024, 2, 1), Description: "b"},
+		{Date: reportDate(2024, 2, 29), Description: "c"},
+		{Date: reportDate(2024, 3, 1), Description: "d"},
+	}
+	tests := []struct {
+		start, end time.Time
+		want       string
+	}{
+		// Start is included and end is not
+		{reportDate(2024, 2, 1), reportDate(2024, 3, 1), "bc"},
+		{reportDate(2023, 1, 1), reportDate(2025, 1, 1), "abcd"},
+		{reportDate(2024, 2, 2), reportDate(2024, 2, 29), ""},
+		{reportDate(2025, 1, 1), reportDate(2026, 1, 1), ""},
+		// An end before the start is an empty range
+		{reportDate(2024, 3, 1), reportDate(2024, 1, 1), ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		for _, txn := range filterByDate(transactions, tt.start, tt.end) {
+			got += txn.Description
+		}
+		if got != tt.want {
+			t.Errorf("transactions from %s to %s = %q, want %q", tt.start.Format(time.DateOnly), tt.end.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
@@ -3,8 +3,6 @@ package ui
 import (
 	"cashd/internal/data"
 	"sort"
-
-	"github.com/charmbracelet/bubbles/table"
 )
 
 type accountColumn int
@@ -15,6 +13,8 @@ const (
 	acctColName
 	acctColIncome
 	acctColExpense
+	acctColTransfers
+	acctColBalance
 
 	totalNumAcctColumns
 )
@@ -24,7 +24,7 @@ func (c accountColumn) index() int {
 }
 
 func (c accountColumn) rightAligned() bool {
-	return c == acctColIncome || c == acctColExpense
+	return c == acctColIncome || c == acctColExpense || c == acctColTransfers || c == acctColBalance
 }
 
 func (c accountColumn) isSortable() bool {
@@ -55,6 +55,10 @@ func (c accountColumn) getColumnData(a any) any {
 		return account.income
 	case acctColExpense:
 		return account.expense
+	case acctColTransfers:
+		return account.transfers
+	case acctColBalance:
+		return account.balance
 	default:
 		return ""
 	}
@@ -72,17 +76,23 @@ func (c accountColumn) String() string {
 		return "Income"
 	case acctColExpense:
 		return "Expense"
+	case acctColTransfers:
+		return "Transfers"
+	case acctColBalance:
+		return "Balance"
 	default:
 		return "Unknown"
 	}
 }
 
 var accountColWidthMap = map[accountColumn]int{
-	acctColSymbol:  symbolColWidth,
-	acctColType:    accountTypeColWidth,
-	acctColName:    accountColWidth,
-	acctColIncome:  amountColWidth,
-	acctColExpense: amountColWidth,
+	acctColSymbol:    symbolColWidth,
+	acctColType:      accountTypeColWidth,
+	acctColName:      accountColWidth,
+	acctColIncome:    amountColWidth,
+	acctColExpense:   amountColWidth,
+	acctColTransfers: amountColWidth,
+	acctColBalance:   amountColWidth,
 }
 
 const AccountNameTotal = "All Accounts"
@@ -110,7 +120,7 @@ var accountTableConfig = tableConfig{
 		return cols
 	}(),
 	dataProvider:      accountTableDataProvider,
-	rowId:             func(row table.Row) string { return row[acctColName] },
+	rowId:             func(a any) string { return a.(*accountInfo).name },
 	defaultSortColumn: column(acctColName),
 	defaultSortDir:    sortAsc,
 }
@@ -119,12 +129,14 @@ type accountInfo struct {
 	accountType data.AccountType
 	symbol      string
 	name        string
-	income      float64
-	expense     float64
+	income      data.Money
+	expense     data.Money
+	transfers   data.Money // Net amount transferred into the account
+	balance     data.Money // Balance at the end of the date range
 }
 
-func accountTableDataProvider(transactions []*data.Transaction) tableDataSorter {
-	accounts := getAccountInfo(transactions)
+func accountTableDataProvider(transactions []*data.Transaction, ctx TableContext) tableDataSorter {
+	accounts := getAccountInfo(transactions, ctx.Balances)
 	result := make([]any, len(accounts))
 	for i, acct := range accounts {
 		result[i] = acct
@@ -147,36 +159,58 @@ func accountTableDataProvider(transactions []*data.Transaction) tableDataSorter
 }
 
 // Get account-level stats by aggregating transactions
-func getAccountInfo(transactions []*data.Transaction) []*accountInfo {
-	var totalIncome, totalExpense float64
+// Accounts with a balance are listed even without transactions in the date range
+func getAccountInfo(transactions []*data.Transaction, balances []data.Balance) []*accountInfo {
+	var totalIncome, totalExpense, totalBalance data.Money
 	accountMap := make(map[string]*accountInfo)
-	for _, tx := range transactions {
-		account, exist := accountMap[tx.Account]
+	getAccount := func(name string, accountType data.AccountType) *accountInfo {
+		account, exist := accountMap[name]
 		if !exist {
 			account = &accountInfo{
-				symbol:      tx.AccountSymbol(),
-				accountType: tx.AccountType,
-				name:        tx.Account,
+				symbol:      data.AccountTypeSymbol(accountType),
+				accountType: accountType,
+				name:        name,
+			}
+			accountMap[name] = account
+		}
+		return account
+	}
+	for _, tx := range transactions {
+		account := getAccount(tx.Account, tx.AccountType)
+		if tx.IsTransfer() {
+			// Transfers show up in both the source and the destination accounts
+			account.transfers -= tx.Amount
+			getAccount(tx.ToAccount, tx.ToAccountType).transfers += tx.Amount
+			continue
+		}
+		for _, split := range tx.CategorySplits() {
+			switch split.Type {
+			case data.Income:
+				account.income += split.Amount
+				totalIncome += split.Amount
+			case data.Expense:
+				account.expense += split.Amount
+				totalExpense += split.Amount
 			}
-			accountMap[tx.Account] = account
 		}
-		if tx.Type == data.Income {
-			account.income += tx.Amount
-			totalIncome += tx.Amount
-		} else {
-			account.expense += tx.Amount
-			totalExpense += tx.Amount
+	}
+	for _, b := range balances {
+		totalBalance += b.Amount
+		if _, exist := accountMap[b.Account]; exist || b.Amount != 0 {
+			getAccount(b.Account, b.AccountType).balance = b.Amount
 		}
 	}
 
 	accounts := []*accountInfo{
 		// Add a pseudo account for "Overall" income and expense
+		// Transfers between accounts cancel out so they are always 0 here
 		{
 			symbol:      "",
 			accountType: data.AcctOverall,
 			name:        AccountNameTotal,
 			income:      totalIncome,
 			expense:     totalExpense,
+			balance:     totalBalance, // Net worth
 		},
 	}
 	for _, a := range accountMap {
@@ -0,0 +1,424 @@
+package ui
+
+import (
+	"cashd/internal/data"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Fields of the add form, in the order they are shown
+// The description comes first so that the other fields can be filled in from a past transaction with it
+const (
+	addDate = iota
+	addType
+	addDescription
+	addAccount
+	addCategory
+	addAmount
+	addFieldCount
+)
+
+var addFieldNames = [addFieldCount]string{"Date", "Type", "Description", "Account", "Category", "Amount"}
+
+var addTypes = []data.TransactionType{data.Expense, data.Income, data.Transfer}
+
+var calendarDayStyle = lipgloss.NewStyle().
+	Background(highlightColor).
+	Foreground(highlightForegroudColor)
+
+// TransactionAddMsg is sent when the user saves the add form, the transaction is not written to the file yet
+type TransactionAddMsg struct {
+	Transaction *data.Transaction
+}
+
+// AddFormModel enters a new transaction, text fields autocomplete from the loaded transactions
+type AddFormModel struct {
+	file    string
+	date    time.Time
+	txnType data.TransactionType
+	inputs  [addFieldCount]textinput.Model
+	focused int
+	errMsg  string
+	visible bool
+	width   int
+	height  int
+
+	suggestions suggestions
+	// Latest transaction of each account and description
+	lastByAccount     map[string]*data.Transaction
+	lastByDescription map[string]*data.Transaction
+
+	next      key.Binding
+	prev      key.Binding
+	left      key.Binding
+	right     key.Binding
+	prevMonth key.Binding
+	nextMonth key.Binding
+	today     key.Binding
+	save      key.Binding
+	cancel    key.Binding
+}
+
+func NewAddFormModel() AddFormModel {
+	m := AddFormModel{
+		next:      key.NewBinding(key.WithKeys("tab", "down")),
+		prev:      key.NewBinding(key.WithKeys("shift+tab", "up")),
+		left:      key.NewBinding(key.WithKeys("h", "left")),
+		right:     key.NewBinding(key.WithKeys("l", "right")),
+		prevMonth: key.NewBinding(key.WithKeys("pgup")),
+		nextMonth: key.NewBinding(key.WithKeys("pgdown")),
+		today:     key.NewBinding(key.WithKeys("t")),
+		save:      key.NewBinding(key.WithKeys("enter")),
+		cancel:    key.NewBinding(key.WithKeys("esc")),
+	}
+	for i := range m.inputs {
+		m.inputs[i] = textinput.New()
+		m.inputs[i].Prompt = ""
+		m.inputs[i].ShowSuggestions = true
+		// Up and down move between fields
+		m.inputs[i].KeyMap.NextSuggestion = key.NewBinding(key.WithKeys("ctrl+n"))
+		m.inputs[i].KeyMap.PrevSuggestion = key.NewBinding(key.WithKeys("ctrl+p"))
+	}
+	m.inputs[addAmount].ShowSuggestions = false
+	return m
+}
+
+// Show an empty form for a transaction added to the file, with suggestions from the transactions
+// An empty file means there is nowhere to add transactions
+func (m *AddFormModel) Show(transactions []*data.Transaction, file string) tea.Cmd {
+	m.file = file
+	m.visible = true
+	m.errMsg = ""
+	if file == "" {
+		m.errMsg = "there is no file to add transactions to, set --add-to"
+	}
+	now := time.Now()
+	m.date = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	m.txnType = data.Expense
+	for i := range m.inputs {
+		m.inputs[i].SetValue("")
+	}
+	m.setSuggestions(transactions)
+	m.updateCategorySuggestions()
+	return m.focus(addDescription)
+}
+
+func (m *AddFormModel) Hide() {
+	m.visible = false
+}
+
+func (m *AddFormModel) Visible() bool {
+	return m.visible
+}
+
+// Show why the transaction could not be added, the form stays open to fix it
+func (m *AddFormModel) SetError(err error) {
+	m.errMsg = err.Error()
+}
+
+func (m *AddFormModel) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+	for i := range m.inputs {
+		m.inputs[i].Width = max(1, width-2*hPadding-detailNameWidth-1-calendarWidth-2)
+	}
+}
+
+// Suggest values of the transactions, which are sorted by date
+func (m *AddFormModel) setSuggestions(transactions []*data.Transaction) {
+	m.suggestions = newSuggestions(transactions)
+	m.lastByAccount = map[string]*data.Transaction{}
+	m.lastByDescription = map[string]*data.Transaction{}
+	for _, t := range transactions {
+		m.lastByAccount[t.Account] = t
+		if t.IsTransfer() {
+			m.lastByAccount[t.ToAccount] = t
+		}
+		if t.Description != "" {
+			m.lastByDescription[t.Description] = t
+		}
+	}
+	m.inputs[addAccount].SetSuggestions(m.suggestions.accounts)
+	m.inputs[addDescription].SetSuggestions(m.suggestions.descriptions)
+}
+
+// Transfers go to an account instead of a category
+func (m *AddFormModel) updateCategorySuggestions() {
+	if m.txnType == data.Transfer {
+		m.inputs[addCategory].SetSuggestions(m.suggestions.accounts)
+	} else {
+		m.inputs[addCategory].SetSuggestions(m.suggestions.categories)
+	}
+}
+
+func (m *AddFormModel) fieldName(field int) string {
+	if field == addCategory && m.txnType == data.Transfer {
+		return "To account"
+	}
+	return addFieldNames[field]
+}
+
+func (m *AddFormModel) focus(field int) tea.Cmd {
+	m.inputs[m.focused].Blur()
+	m.focused = field
+	if field == addDate || field == addType {
+		return nil
+	}
+	return m.inputs[field].Focus()
+}
+
+// Move the focus by step, leaving the description fills in the empty fields from the last transaction with it
+func (m *AddFormModel) moveFocus(step int) tea.Cmd {
+	if m.focused == addDescription {
+		m.fillFromDescription()
+	}
+	return m.focus((m.focused + step + addFieldCount) % addFieldCount)
+}
+
+func (m *AddFormModel) fillFromDescription() {
+	t, ok := m.lastByDescription[strings.TrimSpace(m.inputs[addDescription].Value())]
+	if !ok || m.inputs[addAccount].Value() != "" || m.inputs[addCategory].Value() != "" {
+		return
+	}
+	m.txnType = t.Type
+	m.updateCategorySuggestions()
+	m.inputs[addAccount].SetValue(t.Account)
+	if t.IsTransfer() {
+		m.inputs[addCategory].SetValue(t.ToAccount)
+	} else if len(t.Splits) == 0 {
+		m.inputs[addCategory].SetValue(t.Category)
+	}
+	if m.inputs[addAmount].Value() == "" {
+		m.inputs[addAmount].SetValue(data.FormatAmount(t.EditableFields().Amount, t.SourceCurrency()))
+	}
+	for i := range m.inputs {
+		m.inputs[i].CursorEnd()
+	}
+}
+
+// Complete the focused input with its current suggestion, return false if there is none
+func (m *AddFormModel) acceptSuggestion() bool {
+	input := &m.inputs[m.focused]
+	suggestion := input.CurrentSuggestion()
+	if input.Value() == "" || suggestion == "" || suggestion == input.Value() {
+		return false
+	}
+	input.SetValue(suggestion)
+	input.CursorEnd()
+	return true
+}
+
+func (m *AddFormModel) cycleType(step int) {
+	i := 0
+	for j, t := range addTypes {
+		if t == m.txnType {
+			i = j
+		}
+	}
+	m.txnType = addTypes[(i+step+len(addTypes))%len(addTypes)]
+	m.updateCategorySuggestions()
+}
+
+// Build the transaction from the fields
+// The account type and the default currency come from the last transaction of the account
+func (m *AddFormModel) transaction() (*data.Transaction, error) {
+	value := func(field int) string {
+		return strings.TrimSpace(m.inputs[field].Value())
+	}
+	amount, currency, err := data.ParseAmount(value(addAmount))
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %s", value(addAmount))
+	}
+	t := &data.Transaction{
+		Date:        m.date,
+		Type:        m.txnType,
+		Description: value(addDescription),
+		Account:     value(addAccount),
+		AccountType: m.accountType(value(addAccount)),
+		Amount:      amount,
+		Currency:    currency,
+	}
+	if t.IsTransfer() {
+		t.ToAccount = value(addCategory)
+		t.ToAccountType = m.accountType(t.ToAccount)
+	} else {
+		t.Category = value(addCategory)
+	}
+	if t.Currency == "" {
+		t.Currency = data.ReportingCurrency()
+		if last, ok := m.lastByAccount[t.Account]; ok {
+			t.Currency = last.SourceCurrency()
+		}
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// New accounts are bank accounts
+func (m *AddFormModel) accountType(account string) data.AccountType {
+	if last, ok := m.lastByAccount[account]; ok {
+		if last.Account == account {
+			return last.AccountType
+		}
+		return last.ToAccountType
+	}
+	return data.AcctBankAccount
+}
+
+func (m AddFormModel) Update(msg tea.Msg) (AddFormModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.cancel):
+		m.Hide()
+		return m, nil
+	case key.Matches(keyMsg, m.save):
+		if m.file == "" {
+			return m, nil
+		}
+		m.fillFromDescription()
+		t, err := m.transaction()
+		if err != nil {
+			m.SetError(err)
+			return m, nil
+		}
+		m.errMsg = ""
+		return m, func() tea.Msg {
+			return TransactionAddMsg{Transaction: t}
+		}
+	case key.Matches(keyMsg, m.next):
+		if keyMsg.String() == "tab" && m.acceptSuggestion() {
+			return m, nil
+		}
+		return m, m.moveFocus(1)
+	case key.Matches(keyMsg, m.prev):
+		return m, m.moveFocus(-1)
+	}
+
+	switch m.focused {
+	case addDate:
+		switch {
+		case key.Matches(keyMsg, m.left):
+			m.date = m.date.AddDate(0, 0, -1)
+		case key.Matches(keyMsg, m.right):
+			m.date = m.date.AddDate(0, 0, 1)
+		case key.Matches(keyMsg, m.prevMonth):
+			m.date = m.date.AddDate(0, -1, 0)
+		case key.Matches(keyMsg, m.nextMonth):
+			m.date = m.date.AddDate(0, 1, 0)
+		case key.Matches(keyMsg, m.today):
+			now := time.Now()
+			m.date = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		}
+	case addType:
+		switch {
+		case key.Matches(keyMsg, m.left):
+			m.cycleType(-1)
+		case key.Matches(keyMsg, m.right):
+			m.cycleType(1)
+		}
+	default:
+		var cmd tea.Cmd
+		m.inputs[m.focused], cmd = m.inputs[m.focused].Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// Width of the month calendar next to the fields
+const calendarWidth = 20
+
+// Month of the date with the day highlighted, weeks start on Sunday
+func (m AddFormModel) calendarView() string {
+	lines := []string{
+		lipgloss.PlaceHorizontal(calendarWidth, lipgloss.Center, m.date.Format("January 2006")),
+		"Su Mo Tu We Th Fr Sa",
+	}
+	first := time.Date(m.date.Year(), m.date.Month(), 1, 0, 0, 0, 0, time.Local)
+	days := first.AddDate(0, 1, -1).Day()
+	week := strings.Repeat("   ", int(first.Weekday()))
+	for day := 1; day <= days; day++ {
+		cell := fmt.Sprintf("%2d", day)
+		if day == m.date.Day() {
+			cell = calendarDayStyle.Render(cell)
+		}
+		week += cell
+		if d := first.AddDate(0, 0, day-1); d.Weekday() == time.Saturday || day == days {
+			lines = append(lines, week)
+			week = ""
+		} else {
+			week += " "
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m AddFormModel) View() string {
+	nameStyle := keyStyle.Width(detailNameWidth)
+	marker := func(field int) string {
+		if field == m.focused {
+			return keyStyle.Render("< ")
+		}
+		return ""
+	}
+	fields := []string{}
+	for i, input := range m.inputs {
+		var value string
+		switch i {
+		case addDate:
+			value = marker(i) + m.date.Format("2006-01-02 Mon")
+		case addType:
+			value = marker(i) + string(m.txnType)
+		default:
+			value = input.View()
+		}
+		if i == addDate || i == addType {
+			if i == m.focused {
+				value += keyStyle.Render(" >")
+			}
+		}
+		fields = append(fields, nameStyle.Render(m.fieldName(i))+" "+value)
+	}
+	if m.errMsg != "" {
+		fields = append(fields, "", expenseStyle.Render(m.errMsg))
+	}
+
+	help := ""
+	switch m.focused {
+	case addDate:
+		help = fmt.Sprintf("%s day | %s month | %s today | ", keyStyle.Render("←/→"), keyStyle.Render("PgUp/PgDn"), keyStyle.Render("t"))
+	case addType:
+		help = fmt.Sprintf("%s type | ", keyStyle.Render("←/→"))
+	case addDescription, addAccount, addCategory:
+		help = fmt.Sprintf("%s complete | %s other suggestions | ", keyStyle.Render("tab"), keyStyle.Render("^n/^p"))
+	}
+	help += fmt.Sprintf("%s next field | %s add | %s cancel", keyStyle.Render("tab/↓"), keyStyle.Render("enter"), keyStyle.Render("esc"))
+
+	body := lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(max(1, m.width-2*hPadding-calendarWidth-2)).Render(strings.Join(fields, "\n")),
+		"  ",
+		m.calendarView(),
+	)
+	title := "Add Transaction to " + filepath.Base(m.file)
+	if m.file == "" {
+		title = "Add Transaction"
+	}
+	return lipgloss.NewStyle().
+		Border(getRoundedBorderWithTitle(title, m.width)).
+		BorderForeground(borderColor).
+		Width(m.width).
+		Height(m.height).
+		Padding(vPadding, hPadding).
+		Render(body + "\n\n" + help)
+}
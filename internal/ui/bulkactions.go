@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"cashd/internal/data"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type BulkAction string
+
+const (
+	BulkRecategorize  BulkAction = "Recategorize"
+	BulkChangeAccount BulkAction = "Change account"
+	BulkAddTag        BulkAction = "Add tag"
+	BulkExport        BulkAction = "Export"
+)
+
+// File the marked transactions are exported to unless the user changes it
+const defaultExportFile = "transactions.csv"
+
+// BulkActionMsg is sent when the user confirms a bulk action, the value is the category, account, tag or file
+type BulkActionMsg struct {
+	Action       BulkAction
+	Value        string
+	Transactions []*data.Transaction
+}
+
+// BulkActionsModel applies an action to all transactions marked in the transaction table
+type BulkActionsModel struct {
+	transactions []*data.Transaction
+	suggestions  suggestions
+	// Empty while choosing the action
+	action  BulkAction
+	input   textinput.Model
+	errMsg  string
+	visible bool
+	width   int
+	height  int
+
+	recategorize  key.Binding
+	changeAccount key.Binding
+	addTag        key.Binding
+	export        key.Binding
+	complete      key.Binding
+	confirm       key.Binding
+	cancel        key.Binding
+}
+
+func NewBulkActionsModel() BulkActionsModel {
+	input := textinput.New()
+	input.ShowSuggestions = true
+	return BulkActionsModel{
+		input:         input,
+		recategorize:  key.NewBinding(key.WithKeys("c")),
+		changeAccount: key.NewBinding(key.WithKeys("a")),
+		addTag:        key.NewBinding(key.WithKeys("t")),
+		export:        key.NewBinding(key.WithKeys("x")),
+		complete:      key.NewBinding(key.WithKeys("tab")),
+		confirm:       key.NewBinding(key.WithKeys("enter")),
+		cancel:        key.NewBinding(key.WithKeys("esc")),
+	}
+}
+
+// Show the actions for the marked transactions, with suggestions from all transactions
+func (m *BulkActionsModel) Show(marked, transactions []*data.Transaction) {
+	m.transactions = marked
+	m.suggestions = newSuggestions(transactions)
+	m.action = ""
+	m.errMsg = ""
+	m.visible = len(marked) > 0
+}
+
+func (m *BulkActionsModel) Hide() {
+	m.visible = false
+}
+
+func (m *BulkActionsModel) Visible() bool {
+	return m.visible
+}
+
+// Show why the action failed, the actions then apply to the failed transactions
+func (m *BulkActionsModel) SetFailed(failed []*data.Transaction, err error) {
+	if len(failed) > 0 {
+		m.transactions = failed
+	}
+	m.action = ""
+	m.input.Blur()
+	m.errMsg = err.Error()
+}
+
+func (m *BulkActionsModel) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+	m.input.Width = max(1, width-2*hPadding-len(m.input.Prompt)-1)
+}
+
+func (m *BulkActionsModel) startAction(action BulkAction) tea.Cmd {
+	m.action = action
+	m.errMsg = ""
+	m.input.SetValue("")
+	switch action {
+	case BulkRecategorize:
+		m.input.Prompt = "New category: "
+		m.input.SetSuggestions(m.suggestions.categories)
+	case BulkChangeAccount:
+		m.input.Prompt = "New account: "
+		m.input.SetSuggestions(m.suggestions.accounts)
+	case BulkAddTag:
+		m.input.Prompt = "Tag: "
+		m.input.SetSuggestions(m.suggestions.tags)
+	case BulkExport:
+		m.input.Prompt = "Export to CSV file: "
+		m.input.SetSuggestions(nil)
+		m.input.SetValue(defaultExportFile)
+	}
+	m.input.CursorEnd()
+	m.SetDimensions(m.width, m.height)
+	return m.input.Focus()
+}
+
+func (m BulkActionsModel) Update(msg tea.Msg) (BulkActionsModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.action == "" {
+		switch {
+		case key.Matches(keyMsg, m.cancel):
+			m.Hide()
+		case key.Matches(keyMsg, m.recategorize):
+			return m, m.startAction(BulkRecategorize)
+		case key.Matches(keyMsg, m.changeAccount):
+			return m, m.startAction(BulkChangeAccount)
+		case key.Matches(keyMsg, m.addTag):
+			return m, m.startAction(BulkAddTag)
+		case key.Matches(keyMsg, m.export):
+			return m, m.startAction(BulkExport)
+		}
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.cancel):
+		// Back to the actions
+		m.action = ""
+		m.errMsg = ""
+		m.input.Blur()
+		return m, nil
+	case key.Matches(keyMsg, m.confirm):
+		value := strings.TrimSpace(m.input.Value())
+		if value == "" {
+			return m, nil
+		}
+		action, transactions := m.action, m.transactions
+		return m, func() tea.Msg {
+			return BulkActionMsg{Action: action, Value: value, Transactions: transactions}
+		}
+	case key.Matches(keyMsg, m.complete):
+		if suggestion := m.input.CurrentSuggestion(); suggestion != "" && m.input.Value() != "" {
+			m.input.SetValue(suggestion)
+			m.input.CursorEnd()
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m BulkActionsModel) View() string {
+	var total data.Money
+	for _, t := range m.transactions {
+		for _, split := range t.CategorySplits() {
+			if split.Type == data.Income {
+				total += split.Amount
+			} else {
+				total -= split.Amount
+			}
+		}
+	}
+	lines := []string{fmt.Sprintf("Total: %s", data.FormatReportingAmount(total)), ""}
+	if m.action == "" {
+		lines = append(lines,
+			fmt.Sprintf("%s recategorize", keyStyle.Render("c")),
+			fmt.Sprintf("%s change account", keyStyle.Render("a")),
+			fmt.Sprintf("%s add tag", keyStyle.Render("t")),
+			fmt.Sprintf("%s export", keyStyle.Render("x")),
+		)
+	} else {
+		lines = append(lines, m.input.View())
+	}
+	if m.errMsg != "" {
+		lines = append(lines, "", expenseStyle.Render(m.errMsg))
+	}
+	if m.action == "" {
+		lines = append(lines, "", fmt.Sprintf("%s close", keyStyle.Render("esc")))
+	} else {
+		lines = append(lines, "", fmt.Sprintf("%s complete | %s %s | %s back",
+			keyStyle.Render("tab"),
+			keyStyle.Render("enter"),
+			strings.ToLower(string(m.action)),
+			keyStyle.Render("esc"),
+		))
+	}
+
+	title := fmt.Sprintf("Bulk Actions: %d marked transactions", len(m.transactions))
+	return lipgloss.NewStyle().
+		Border(getRoundedBorderWithTitle(title, m.width)).
+		BorderForeground(borderColor).
+		Width(m.width).
+		Height(m.height).
+		Padding(vPadding, hPadding).
+		Render(strings.Join(lines, "\n"))
+}
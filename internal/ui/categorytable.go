@@ -2,9 +2,10 @@ package ui
 
 import (
 	"cashd/internal/data"
+	"fmt"
+	"math"
 	"sort"
-
-	"github.com/charmbracelet/bubbles/table"
+	"strings"
 )
 
 type categoryColumn int
@@ -15,6 +16,8 @@ const (
 	catColName
 	catColNumTxns
 	catColAmount
+	catColBudget
+	catColRemaining
 
 	totalNumCatColumns
 )
@@ -24,7 +27,7 @@ func (c categoryColumn) index() int {
 }
 
 func (c categoryColumn) rightAligned() bool {
-	return c == catColNumTxns || c == catColAmount
+	return c == catColNumTxns || c == catColAmount || c == catColRemaining
 }
 
 func (c categoryColumn) isSortable() bool {
@@ -55,6 +58,10 @@ func (c categoryColumn) getColumnData(a any) any {
 		return category.numTxns
 	case catColAmount:
 		return category.amount
+	case catColBudget:
+		return budgetProgress{category.amount, category.budget}
+	case catColRemaining:
+		return budgetRemaining{category.amount, category.budget}
 	default:
 		return ""
 	}
@@ -72,17 +79,23 @@ func (c categoryColumn) String() string {
 		return "Txn #"
 	case catColAmount:
 		return "Amount"
+	case catColBudget:
+		return "Budget"
+	case catColRemaining:
+		return "Remaining"
 	default:
 		return "Unknown"
 	}
 }
 
 var categoryColWidthMap = map[categoryColumn]int{
-	catColSymbol:  symbolColWidth,
-	catColType:    typeColWidth,
-	catColName:    categoryColWidth,
-	catColNumTxns: numberColWidth,
-	catColAmount:  amountColWidth,
+	catColSymbol:    symbolColWidth,
+	catColType:      typeColWidth,
+	catColName:      categoryColWidth,
+	catColNumTxns:   numberColWidth,
+	catColAmount:    amountColWidth,
+	catColBudget:    progressColWidth,
+	catColRemaining: amountColWidth,
 }
 
 var CategoryTableWidth = func() int {
@@ -108,9 +121,10 @@ var categoryTableConfig = tableConfig{
 		return cols
 	}(),
 	dataProvider:      categoryTableDataProvider,
-	rowId:             func(row table.Row) string { return row[catColName] },
+	rowId:             func(a any) string { return a.(*categoryInfo).name },
 	defaultSortColumn: column(catColName),
 	defaultSortDir:    sortAsc,
+	treeColumn:        column(catColName),
 }
 
 type categoryInfo struct {
@@ -118,11 +132,28 @@ type categoryInfo struct {
 	symbol  string
 	name    string
 	numTxns int
-	amount  float64
+	amount  data.Money
+	budget  data.Money // 0 if the category has no budget
+}
+
+func (c *categoryInfo) nodeId() string {
+	return c.name
 }
 
-func categoryTableDataProvider(transactions []*data.Transaction) tableDataSorter {
-	categories := getCategoryInfo(transactions)
+func (c *categoryInfo) parentId() string {
+	return data.ParentCategory(c.name)
+}
+
+// The last level of the category, e.g. Restaurants for Food:Restaurants
+func (c *categoryInfo) label() string {
+	if parent := c.parentId(); parent != "" {
+		return strings.TrimPrefix(c.name, parent+data.CategorySeparator)
+	}
+	return c.name
+}
+
+func categoryTableDataProvider(transactions []*data.Transaction, ctx TableContext) tableDataSorter {
+	categories := getCategoryInfo(transactions, ctx.Budgets)
 	result := make([]any, len(categories))
 	for i, cat := range categories {
 		result[i] = cat
@@ -136,21 +167,42 @@ func categoryTableDataProvider(transactions []*data.Transaction) tableDataSorter
 	}
 }
 
-// Get category-level stats by aggregating transactions
-func getCategoryInfo(transactions []*data.Transaction) []*categoryInfo {
+// Get category-level stats by aggregating transactions, parent categories include all their descendants
+// Categories with a budget are listed even without transactions in the date range
+func getCategoryInfo(transactions []*data.Transaction, budgets map[string]data.Money) []*categoryInfo {
 	categoryMap := make(map[string]*categoryInfo)
-	for _, tx := range transactions {
-		cat, exist := categoryMap[tx.Category]
+	getCategory := func(name string, catType data.TransactionType) *categoryInfo {
+		cat, exist := categoryMap[name]
 		if !exist {
 			cat = &categoryInfo{
-				symbol:  tx.Symbol(),
-				catType: tx.Type,
-				name:    tx.Category,
+				symbol:  data.TransactionTypeSymbol(catType),
+				catType: catType,
+				name:    name,
+			}
+			categoryMap[name] = cat
+		}
+		return cat
+	}
+	for _, tx := range transactions {
+		// Each split counts towards its own category and its parents, but a transaction is counted once per category
+		counted := map[string]bool{}
+		for _, split := range tx.CategorySplits() {
+			for name := split.Category; name != ""; name = data.ParentCategory(name) {
+				cat := getCategory(name, split.Type)
+				if !counted[name] {
+					cat.numTxns++
+					counted[name] = true
+				}
+				cat.amount += split.Amount
 			}
-			categoryMap[tx.Category] = cat
 		}
-		cat.numTxns++
-		cat.amount += tx.Amount
+	}
+	for name, budget := range budgets {
+		// Budgets are usually for expenses, parents of budgets are listed too
+		getCategory(name, data.Expense).budget = budget
+		for parent := data.ParentCategory(name); parent != ""; parent = data.ParentCategory(parent) {
+			getCategory(parent, data.Expense)
+		}
 	}
 
 	categories := []*categoryInfo{}
@@ -159,3 +211,78 @@ func getCategoryInfo(transactions []*data.Transaction) []*categoryInfo {
 	}
 	return categories
 }
+
+const budgetBarWidth = 8
+
+const overBudgetSymbol = "\uf071"
+
+// Progress of a category against its budget, budget is 0 if the category has no budget
+type budgetProgress struct {
+	amount data.Money
+	budget data.Money
+}
+
+// Categories without a budget come before all others
+func (b budgetProgress) ratio() float64 {
+	if b.budget == 0 {
+		return -1
+	}
+	return b.amount.Float64() / b.budget.Float64()
+}
+
+// A progress bar with percentage, e.g. ██████░░  75%, over budget is marked with a warning symbol
+func (b budgetProgress) String() string {
+	if b.budget == 0 {
+		return ""
+	}
+	ratio := b.ratio()
+	filled := int(math.Round(min(ratio, 1) * budgetBarWidth))
+	s := fmt.Sprintf(
+		"%s%s %4.0f%%",
+		strings.Repeat("█", filled),
+		strings.Repeat("░", budgetBarWidth-filled),
+		ratio*100,
+	)
+	if ratio > 1 {
+		s += " " + overBudgetSymbol
+	}
+	return s
+}
+
+func (b budgetProgress) Less(other sortableValue) bool {
+	return b.ratio() < other.(budgetProgress).ratio()
+}
+
+// Reports show the budget itself
+func (b budgetProgress) reportValue() any {
+	if b.budget == 0 {
+		return nil
+	}
+	return b.budget
+}
+
+// Amount left in the budget of a category, negative if over budget
+type budgetRemaining budgetProgress
+
+func (b budgetRemaining) String() string {
+	if b.budget == 0 {
+		return ""
+	}
+	return (b.budget - b.amount).Format()
+}
+
+// Categories without a budget come before all others
+func (b budgetRemaining) Less(other sortableValue) bool {
+	o := other.(budgetRemaining)
+	if b.budget == 0 || o.budget == 0 {
+		return b.budget == 0 && o.budget != 0
+	}
+	return b.budget-b.amount < o.budget-o.amount
+}
+
+func (b budgetRemaining) reportValue() any {
+	if b.budget == 0 {
+		return nil
+	}
+	return b.budget - b.amount
+}
@@ -65,7 +65,13 @@ func (m *DatePickerModel) SetWidth(w int) {
 func (m *DatePickerModel) SetLimits(minDate, maxDate time.Time) {
 	m.minDate = minDate
 	m.maxDate = maxDate
-	m.clampDateRangeToLimits()
+	if m.inc == date.AllTime {
+		// All time follows the limits, e.g. when reloaded data has new dates
+		m.startDate = m.minDate
+		m.endDate = m.maxDate
+	} else {
+		m.clampDateRangeToLimits()
+	}
 }
 
 func (m *DatePickerModel) minStartDate() time.Time {
@@ -201,8 +207,12 @@ func (m DatePickerModel) View() string {
 
 	// Add spces to align rightStr to right side
 	spaces := m.width - hPadding*2 - lipgloss.Width(leftStr.String()) - lipgloss.Width(rightStr.String())
+	if spaces < 1 {
+		// Not enough room for key bindings, which are also in the help panel
+		return style.Render(leftStr.String())
+	}
 	return style.
-		Render(leftStr.String() + strings.Repeat(" ", max(0, spaces)) + rightStr.String())
+		Render(leftStr.String() + strings.Repeat(" ", spaces) + rightStr.String())
 }
 
 func (m *DatePickerModel) Inc() date.Increment {
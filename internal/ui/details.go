@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"cashd/internal/data"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Width of the field names in the details panel
+const detailNameWidth = 14
+
+// DetailsModel shows every field of a transaction, including those cut off or left out by the table
+type DetailsModel struct {
+	txn      *data.Transaction
+	visible  bool
+	viewport viewport.Model
+	width    int
+	height   int
+}
+
+func NewDetailsModel() DetailsModel {
+	return DetailsModel{
+		viewport: viewport.New(0, 0),
+	}
+}
+
+// Show the transaction, nil hides the panel
+func (m *DetailsModel) Show(txn *data.Transaction) {
+	m.txn = txn
+	m.visible = txn != nil
+	m.viewport.GotoTop()
+	m.updateContent()
+}
+
+func (m *DetailsModel) Hide() {
+	m.visible = false
+}
+
+func (m *DetailsModel) Visible() bool {
+	return m.visible
+}
+
+func (m *DetailsModel) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+	m.viewport.Width = max(0, width-2*hPadding)
+	m.viewport.Height = max(0, height-2*vPadding)
+	m.updateContent()
+}
+
+func (m *DetailsModel) updateContent() {
+	if m.txn == nil {
+		m.viewport.SetContent("")
+		return
+	}
+	t := m.txn
+
+	nameStyle := keyStyle.Width(detailNameWidth)
+	valueStyle := lipgloss.NewStyle().Width(max(1, m.viewport.Width-detailNameWidth))
+	lines := []string{}
+	add := func(name, value string) {
+		if value == "" {
+			return
+		}
+		// Long values wrap next to their name
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top, nameStyle.Render(name), valueStyle.Render(value)))
+	}
+
+	add("Date", t.Date.Format(time.DateOnly))
+	add("Type", string(t.Type))
+	add("Account", formatDetailAccount(t.Account, t.AccountType))
+	if t.IsTransfer() {
+		add("To account", formatDetailAccount(t.ToAccount, t.ToAccountType))
+	}
+	add("Category", t.Category)
+	for _, s := range t.Splits {
+		add("", fmt.Sprintf("%s %s %s", s.Category, data.FormatAmount(s.Amount, t.Currency), s.Type))
+	}
+	add("Amount", t.FormattedAmount())
+	if t.IsConverted() {
+		add("Original", data.FormatAmount(t.OriginalAmount, t.OriginalCurrency))
+	}
+	add("Description", t.Description)
+	add("Tags", strings.Join(t.Tags, ", "))
+	for _, tag := range slices.Sorted(maps.Keys(t.Metadata)) {
+		add(tag, t.Metadata[tag])
+	}
+	add("Source", t.Source)
+	if t.File != "" {
+		add("File", fmt.Sprintf("%s:%d", t.File, t.Line))
+	}
+	add("Rule", t.Rule)
+
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+func formatDetailAccount(account string, accountType data.AccountType) string {
+	if accountType == data.AcctOverall {
+		return account
+	}
+	return fmt.Sprintf("%s (%s)", account, accountType)
+}
+
+func (m DetailsModel) Update(msg tea.Msg) (DetailsModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m DetailsModel) View() string {
+	title := "Transaction Details"
+	return lipgloss.NewStyle().
+		Border(getRoundedBorderWithTitle(title, m.width)).
+		BorderForeground(borderColor).
+		Width(m.width).
+		Height(m.height).
+		Padding(vPadding, hPadding).
+		Render(m.viewport.View())
+}
@@ -0,0 +1,151 @@
+package ui
+
+import (
+	"cashd/internal/data"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Lines taken by each pair in the list, including the empty line between pairs
+const duplicatePairHeight = 4
+
+// DuplicateDroppedMsg is sent when the user drops a duplicate, the data has to be reloaded without it
+type DuplicateDroppedMsg struct{}
+
+// DuplicatesModel lists possible duplicate transactions for the user to keep or drop
+type DuplicatesModel struct {
+	pairs   []*data.DuplicatePair
+	cursor  int
+	visible bool
+	width   int
+	height  int
+
+	down     key.Binding
+	up       key.Binding
+	drop     key.Binding
+	keepBoth key.Binding
+}
+
+func NewDuplicatesModel() DuplicatesModel {
+	return DuplicatesModel{
+		down:     key.NewBinding(key.WithKeys("j", "down")),
+		up:       key.NewBinding(key.WithKeys("k", "up")),
+		drop:     key.NewBinding(key.WithKeys("d")),
+		keepBoth: key.NewBinding(key.WithKeys("n")),
+	}
+}
+
+func (m *DuplicatesModel) SetPairs(pairs []*data.DuplicatePair) {
+	m.pairs = pairs
+	m.cursor = max(0, min(m.cursor, len(pairs)-1))
+	if len(pairs) == 0 {
+		m.visible = false
+	}
+}
+
+func (m *DuplicatesModel) Count() int {
+	return len(m.pairs)
+}
+
+// Toggle the panel, it is never shown without pairs to review
+func (m *DuplicatesModel) ToggleVisibility() {
+	m.visible = !m.visible && len(m.pairs) > 0
+}
+
+func (m *DuplicatesModel) Visible() bool {
+	return m.visible
+}
+
+func (m *DuplicatesModel) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+func (m DuplicatesModel) Update(msg tea.Msg) (DuplicatesModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || len(m.pairs) == 0 {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.down):
+		m.cursor = min(m.cursor+1, len(m.pairs)-1)
+	case key.Matches(keyMsg, m.up):
+		m.cursor = max(m.cursor-1, 0)
+	case key.Matches(keyMsg, m.keepBoth):
+		m.decide(data.KeepBoth)
+	case key.Matches(keyMsg, m.drop):
+		m.decide(data.DropDuplicate)
+		return m, func() tea.Msg {
+			return DuplicateDroppedMsg{}
+		}
+	}
+	return m, nil
+}
+
+// Remember the decision for the selected pair and remove it from the list
+func (m *DuplicatesModel) decide(decision data.DuplicateDecision) {
+	pair := m.pairs[m.cursor]
+	if err := data.SaveDuplicateDecision(pair, decision); err != nil {
+		log.Printf("Failed to save duplicate decision: %v\n", err)
+	}
+	pairs := append([]*data.DuplicatePair{}, m.pairs[:m.cursor]...)
+	m.SetPairs(append(pairs, m.pairs[m.cursor+1:]...))
+}
+
+func (m DuplicatesModel) View() string {
+	// Scroll so that the selected pair is always shown, the last line is for the keys
+	pageSize := max(1, (m.height-2*vPadding-1)/duplicatePairHeight)
+	start := max(0, m.cursor-pageSize+1)
+	end := min(len(m.pairs), start+pageSize)
+
+	lines := []string{}
+	for i := start; i < end; i++ {
+		p := m.pairs[i]
+		t := p.Original
+		marker := " "
+		if i == m.cursor {
+			marker = keyStyle.Render(">")
+		}
+		lines = append(lines,
+			fmt.Sprintf("%s %s %s %s (%.0f%% similar)",
+				marker,
+				t.Account,
+				data.FormatAmount(t.Amount, t.Currency),
+				t.Type,
+				p.Similarity*100,
+			),
+			fmt.Sprintf("    keep: %s", formatDuplicate(p.Original)),
+			fmt.Sprintf("    drop: %s", formatDuplicate(p.Duplicate)),
+			"",
+		)
+	}
+	lines = append(lines, fmt.Sprintf(
+		"%s drop duplicate | %s keep both | %s close",
+		keyStyle.Render("d"),
+		keyStyle.Render("n"),
+		keyStyle.Render("esc"),
+	))
+
+	title := fmt.Sprintf("Possible Duplicates: %d pairs to review", len(m.pairs))
+	return lipgloss.NewStyle().
+		Border(getRoundedBorderWithTitle(title, m.width)).
+		BorderForeground(borderColor).
+		Width(m.width).
+		Height(m.height).
+		Padding(vPadding, hPadding).
+		Render(strings.Join(lines, "\n"))
+}
+
+func formatDuplicate(t *data.Transaction) string {
+	category := t.Category
+	if t.IsTransfer() {
+		category = "to " + t.ToAccount
+	}
+	return fmt.Sprintf("%s %s | %s [%s]", t.Date.Format(time.DateOnly), t.Description, category, t.Source)
+}
@@ -0,0 +1,224 @@
+package ui
+
+import (
+	"cashd/internal/data"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Fields of the edit form, in the order they are shown
+const (
+	editDate = iota
+	editAccount
+	editCategory
+	editDescription
+	editAmount
+	editFieldCount
+)
+
+var editFieldNames = [editFieldCount]string{"Date", "Account", "Category", "Description", "Amount"}
+
+// TransactionEditMsg is sent when the user saves the edit form, the edit is not written to the file yet
+type TransactionEditMsg struct {
+	Transaction *data.Transaction
+	Edit        data.TransactionEdit
+}
+
+// EditFormModel edits the fields of a transaction that can be written back to its file
+type EditFormModel struct {
+	txn     *data.Transaction
+	inputs  [editFieldCount]textinput.Model
+	focused int
+	errMsg  string
+	visible bool
+	width   int
+	height  int
+
+	next   key.Binding
+	prev   key.Binding
+	save   key.Binding
+	cancel key.Binding
+}
+
+func NewEditFormModel() EditFormModel {
+	m := EditFormModel{
+		next:   key.NewBinding(key.WithKeys("tab", "down")),
+		prev:   key.NewBinding(key.WithKeys("shift+tab", "up")),
+		save:   key.NewBinding(key.WithKeys("enter")),
+		cancel: key.NewBinding(key.WithKeys("esc")),
+	}
+	for i := range m.inputs {
+		m.inputs[i] = textinput.New()
+		m.inputs[i].Prompt = ""
+	}
+	m.inputs[editDate].Placeholder = time.DateOnly
+	m.inputs[editDate].CharLimit = len(time.DateOnly)
+	return m
+}
+
+// Show the form filled with the fields of the transaction
+func (m *EditFormModel) Show(txn *data.Transaction) tea.Cmd {
+	fields := txn.EditableFields()
+	m.txn = txn
+	m.visible = true
+	m.errMsg = ""
+	m.inputs[editDate].SetValue(fields.Date.Format(time.DateOnly))
+	m.inputs[editAccount].SetValue(fields.Account)
+	m.inputs[editCategory].SetValue(fields.Category)
+	m.inputs[editDescription].SetValue(fields.Description)
+	m.inputs[editAmount].SetValue(fields.Amount.String())
+	for i := range m.inputs {
+		m.inputs[i].CursorEnd()
+	}
+	return m.focus(editDate)
+}
+
+func (m *EditFormModel) Hide() {
+	m.visible = false
+	m.txn = nil
+}
+
+func (m *EditFormModel) Visible() bool {
+	return m.visible
+}
+
+// Show why the edit could not be saved, the form stays open to fix it
+func (m *EditFormModel) SetError(err error) {
+	m.errMsg = err.Error()
+}
+
+func (m *EditFormModel) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+	for i := range m.inputs {
+		m.inputs[i].Width = max(1, width-2*hPadding-detailNameWidth-1)
+	}
+}
+
+// Whether the field can be edited, transfers have no category
+func (m *EditFormModel) editable(field int) bool {
+	return field != editCategory || !m.txn.IsTransfer()
+}
+
+func (m *EditFormModel) focus(field int) tea.Cmd {
+	m.inputs[m.focused].Blur()
+	m.focused = field
+	return m.inputs[field].Focus()
+}
+
+// Move the focus by step, skipping fields that can't be edited
+func (m *EditFormModel) moveFocus(step int) tea.Cmd {
+	field := m.focused
+	for {
+		field = (field + step + editFieldCount) % editFieldCount
+		if m.editable(field) {
+			return m.focus(field)
+		}
+	}
+}
+
+// Read the edit from the inputs
+func (m *EditFormModel) edit() (data.TransactionEdit, error) {
+	value := func(field int) string {
+		return strings.TrimSpace(m.inputs[field].Value())
+	}
+	d, err := time.ParseInLocation(time.DateOnly, value(editDate), time.Local)
+	if err != nil {
+		return data.TransactionEdit{}, fmt.Errorf("invalid date, use %s", time.DateOnly)
+	}
+	amount, err := data.ParseMoney(value(editAmount))
+	if err != nil {
+		return data.TransactionEdit{}, fmt.Errorf("invalid amount: %s", value(editAmount))
+	}
+	edit := data.TransactionEdit{
+		Date:        d,
+		Account:     value(editAccount),
+		Category:    value(editCategory),
+		Description: value(editDescription),
+		Amount:      amount,
+	}
+	if err := edit.Validate(m.txn); err != nil {
+		return data.TransactionEdit{}, err
+	}
+	return edit, nil
+}
+
+func (m EditFormModel) Update(msg tea.Msg) (EditFormModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || m.txn == nil {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.cancel):
+		m.Hide()
+	case key.Matches(keyMsg, m.next):
+		return m, m.moveFocus(1)
+	case key.Matches(keyMsg, m.prev):
+		return m, m.moveFocus(-1)
+	case key.Matches(keyMsg, m.save):
+		edit, err := m.edit()
+		if err != nil {
+			m.SetError(err)
+			return m, nil
+		}
+		if !edit.Changes(m.txn) {
+			// Nothing to write
+			m.Hide()
+			return m, nil
+		}
+		txn := m.txn
+		m.errMsg = ""
+		return m, func() tea.Msg {
+			return TransactionEditMsg{Transaction: txn, Edit: edit}
+		}
+	default:
+		var cmd tea.Cmd
+		m.inputs[m.focused], cmd = m.inputs[m.focused].Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m EditFormModel) View() string {
+	if m.txn == nil {
+		return ""
+	}
+	nameStyle := keyStyle.Width(detailNameWidth)
+	lines := []string{}
+	for i, input := range m.inputs {
+		if !m.editable(i) {
+			continue
+		}
+		lines = append(lines, nameStyle.Render(editFieldNames[i])+" "+input.View())
+	}
+	if m.txn.IsTransfer() {
+		lines = append(lines, nameStyle.Render("To account")+" "+m.txn.ToAccount)
+	}
+	if m.txn.IsConverted() {
+		lines = append(lines, "", fmt.Sprintf("The amount is in %s, as in %s", m.txn.OriginalCurrency, m.txn.Source))
+	}
+	if m.errMsg != "" {
+		lines = append(lines, "", expenseStyle.Render(m.errMsg))
+	}
+	lines = append(lines, "", fmt.Sprintf(
+		"%s next field | %s save | %s cancel",
+		keyStyle.Render("tab"),
+		keyStyle.Render("enter"),
+		keyStyle.Render("esc"),
+	))
+
+	title := "Edit Transaction"
+	return lipgloss.NewStyle().
+		Border(getRoundedBorderWithTitle(title, m.width)).
+		BorderForeground(borderColor).
+		Width(m.width).
+		Height(m.height).
+		Padding(vPadding, hPadding).
+		Render(strings.Join(lines, "\n"))
+}
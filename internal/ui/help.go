@@ -42,11 +42,36 @@ func (m HelpModel) View() string {
 	}
 	var s strings.Builder
 	s.WriteString(fmt.Sprintf(
-		"General: %s quit | %s toggle help | %s search transactiona | %s clear search\n",
+		"General: %s quit | %s toggle help | %s search transactiona | %s clear search | %s import problems | %s duplicates\n",
 		keyStyle.Render("^c"),
 		keyStyle.Render("?"),
 		keyStyle.Render("/"),
 		keyStyle.Render("esc"),
+		keyStyle.Render("!"),
+		keyStyle.Render("D"),
+	))
+	s.WriteString(fmt.Sprintf(
+		"Rows: %s details of a transaction, or transactions of an account, category or tag | %s edit | %s add transaction\n",
+		keyStyle.Render("enter"),
+		keyStyle.Render("e"),
+		keyStyle.Render("n"),
+	))
+	s.WriteString(fmt.Sprintf(
+		"Marks: %s mark transaction | %s mark all shown | %s bulk actions on marked\n",
+		keyStyle.Render("space"),
+		keyStyle.Render("*"),
+		keyStyle.Render("b"),
+	))
+	s.WriteString(fmt.Sprintf(
+		"Date: %s prev | %s next | %s now | %s weekly | %s monthly | %s quarterly | %s yearly | %s all time\n",
+		keyStyle.Render("h/←"),
+		keyStyle.Render("l/→"),
+		keyStyle.Render("0"),
+		keyStyle.Render("w"),
+		keyStyle.Render("m"),
+		keyStyle.Render("q"),
+		keyStyle.Render("y"),
+		keyStyle.Render("a"),
 	))
 	s.WriteString(fmt.Sprintf(
 		"Table: %s down | %s up | %s pgDown | %s pgUp | %s top | %s bottom\n",
@@ -63,5 +88,11 @@ func (m HelpModel) View() string {
 		keyStyle.Render("S"),
 		keyStyle.Render("r"),
 	))
+	s.WriteString(fmt.Sprintf(
+		"\nCategories: %s expand/collapse | %s show more levels | %s show fewer levels",
+		keyStyle.Render("e"),
+		keyStyle.Render("+"),
+		keyStyle.Render("-"),
+	))
 	return baseStyle.Width(m.width).Render(s.String())
 }
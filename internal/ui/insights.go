@@ -12,12 +12,21 @@ import (
 )
 
 type insight struct {
-	income         float64
-	expense        float64
+	income         data.Money
+	expense        data.Money
 	incomeTxnNum   int
 	expenseTxnNum  int
-	topIncomeTxns  []*data.Transaction
-	topExpenseTxns []*data.Transaction
+	topIncomeTxns  []insightTxn
+	topExpenseTxns []insightTxn
+	transferIn     data.Money
+	transferOut    data.Money
+	transferTxnNum int
+}
+
+// A transaction with the amount attributed to the insight
+type insightTxn struct {
+	txn    *data.Transaction
+	amount data.Money
 }
 
 const (
@@ -27,6 +36,7 @@ const (
 type InsightsModel struct {
 	name   string
 	ins    insight
+	budget data.Money // 0 if there is no budget
 	width  int
 	height int
 }
@@ -36,43 +46,78 @@ func NewInsightsModel() InsightsModel {
 }
 
 func (m *InsightsModel) SetTransactionsWithAccount(transactions []*data.Transaction, account string) {
-	m.updateInsights(transactions, func(t *data.Transaction) bool {
-		return account == AccountNameTotal || t.Account == account
+	m.updateInsights(transactions, func(t *data.Transaction) []data.Split {
+		if account == AccountNameTotal || t.Account == account {
+			return t.CategorySplits()
+		}
+		return nil
 	})
+
+	// Transfers are neither income nor expense, count them separately
+	for _, t := range transactions {
+		if !t.IsTransfer() || (account != AccountNameTotal && !t.InvolvesAccount(account)) {
+			continue
+		}
+		m.ins.transferTxnNum++
+		if account == AccountNameTotal || t.Account == account {
+			m.ins.transferOut += t.Amount
+		}
+		if account == AccountNameTotal || t.ToAccount == account {
+			m.ins.transferIn += t.Amount
+		}
+	}
 }
 
 func (m *InsightsModel) SetTransactionsWithCategory(transactions []*data.Transaction, category string) {
-	m.updateInsights(transactions, func(t *data.Transaction) bool {
-		return t.Category == category
+	m.updateInsights(transactions, func(t *data.Transaction) []data.Split {
+		return t.SplitsInCategory(category)
 	})
 }
 
-func (m *InsightsModel) updateInsights(transactions []*data.Transaction, match func(*data.Transaction) bool) {
+// The whole transaction counts towards each of its tags
+func (m *InsightsModel) SetTransactionsWithTag(transactions []*data.Transaction, tag string) {
+	m.updateInsights(transactions, func(t *data.Transaction) []data.Split {
+		if t.HasTag(tag) {
+			return t.CategorySplits()
+		}
+		return nil
+	})
+}
+
+// matchingSplits returns the splits of a transaction that count towards the insight
+func (m *InsightsModel) updateInsights(transactions []*data.Transaction, matchingSplits func(*data.Transaction) []data.Split) {
 	m.ins = insight{}
-	incomeTxns := []*data.Transaction{}
-	expenseTxns := []*data.Transaction{}
+	incomeTxns := []insightTxn{}
+	expenseTxns := []insightTxn{}
 
 	for _, t := range transactions {
-		if match(t) {
-			switch t.Type {
+		var income, expense data.Money
+		for _, s := range matchingSplits(t) {
+			switch s.Type {
 			case data.Income:
-				m.ins.income += t.Amount
-				incomeTxns = append(incomeTxns, t)
+				income += s.Amount
 			case data.Expense:
-				m.ins.expense += t.Amount
-				expenseTxns = append(expenseTxns, t)
+				expense += s.Amount
 			}
 		}
+		if income > 0 {
+			m.ins.income += income
+			incomeTxns = append(incomeTxns, insightTxn{t, income})
+		}
+		if expense > 0 {
+			m.ins.expense += expense
+			expenseTxns = append(expenseTxns, insightTxn{t, expense})
+		}
 	}
 
 	m.ins.incomeTxnNum = len(incomeTxns)
 	m.ins.expenseTxnNum = len(expenseTxns)
 
 	sort.Slice(incomeTxns, func(i, j int) bool {
-		return incomeTxns[i].Amount > incomeTxns[j].Amount
+		return incomeTxns[i].amount > incomeTxns[j].amount
 	})
 	sort.Slice(expenseTxns, func(i, j int) bool {
-		return expenseTxns[i].Amount > expenseTxns[j].Amount
+		return expenseTxns[i].amount > expenseTxns[j].amount
 	})
 	if m.ins.incomeTxnNum <= topTxnNum {
 		m.ins.topIncomeTxns = incomeTxns
@@ -86,6 +131,11 @@ func (m *InsightsModel) updateInsights(transactions []*data.Transaction, match f
 	}
 }
 
+// Set the budget for the date range, 0 means no budget
+func (m *InsightsModel) SetBudget(budget data.Money) {
+	m.budget = budget
+}
+
 func (m *InsightsModel) SetDimension(width, height int) {
 	m.width = width
 	m.height = height
@@ -105,31 +155,63 @@ func (m InsightsModel) View() string {
 	if m.ins.incomeTxnNum > 0 {
 		s.WriteString("\n")
 		s.WriteString(fmt.Sprintf(
-			"%s Income: $%s in %d transactions\n",
+			"%s Income: %s in %d transactions\n",
 			incomeStyle.Render(string(runes.FullBlock)),
-			data.FormatMoney(m.ins.income),
+			data.FormatReportingAmount(m.ins.income),
 			m.ins.incomeTxnNum,
 		))
 		s.WriteString("Top transactions:\n")
 		for _, t := range m.ins.topIncomeTxns {
-			s.WriteString(formatTransaction(t))
+			s.WriteString(formatTransaction(t.txn, t.amount))
 		}
 	}
 
 	if m.ins.expenseTxnNum > 0 {
 		s.WriteString("\n")
 		s.WriteString(fmt.Sprintf(
-			"%s Expense: $%s in %d transactions\n",
+			"%s Expense: %s in %d transactions\n",
 			expenseStyle.Render(string(runes.FullBlock)),
-			data.FormatMoney(m.ins.expense),
+			data.FormatReportingAmount(m.ins.expense),
 			m.ins.expenseTxnNum,
 		))
 		s.WriteString("Top transactions:\n")
 		for _, t := range m.ins.topExpenseTxns {
-			s.WriteString(formatTransaction(t))
+			s.WriteString(formatTransaction(t.txn, t.amount))
 		}
 	}
 
+	if m.ins.transferTxnNum > 0 {
+		s.WriteString("\n")
+		s.WriteString(fmt.Sprintf(
+			"Transfers: %s in, %s out in %d transactions\n",
+			data.FormatReportingAmount(m.ins.transferIn),
+			data.FormatReportingAmount(m.ins.transferOut),
+			m.ins.transferTxnNum,
+		))
+	}
+
+	if m.budget > 0 {
+		s.WriteString("\n")
+		spent := m.ins.income + m.ins.expense
+		if spent > m.budget {
+			s.WriteString(expenseStyle.Render(fmt.Sprintf(
+				"%s Budget: %s of %s, %s over",
+				overBudgetSymbol,
+				data.FormatReportingAmount(spent),
+				data.FormatReportingAmount(m.budget),
+				data.FormatReportingAmount(spent-m.budget),
+			)))
+		} else {
+			s.WriteString(fmt.Sprintf(
+				"Budget: %s of %s, %s left",
+				data.FormatReportingAmount(spent),
+				data.FormatReportingAmount(m.budget),
+				data.FormatReportingAmount(m.budget-spent),
+			))
+		}
+		s.WriteString("\n")
+	}
+
 	return lipgloss.NewStyle().
 		Border(getRoundedBorderWithTitle(m.name, m.width)).
 		BorderForeground(borderColor).
@@ -139,12 +221,12 @@ func (m InsightsModel) View() string {
 		Render(s.String())
 }
 
-func formatTransaction(t *data.Transaction) string {
+func formatTransaction(t *data.Transaction, amount data.Money) string {
 	return fmt.Sprintf(
 		"%s %*s %s\n",
 		t.Date.Format(time.DateOnly),
 		amountColWidth,
-		fmt.Sprintf("$%s", t.FormattedAmount()),
+		data.FormatReportingAmount(amount),
 		t.Description,
 	)
 }
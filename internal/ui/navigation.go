@@ -15,17 +15,24 @@ const (
 	TransactionView ViewMode = "Transactions"
 	AccountView     ViewMode = "Accounts"
 	CategoryView    ViewMode = "Categories"
+	NetWorthView    ViewMode = "Net Worth"
+	TagView         ViewMode = "Tags"
 )
 
-const NavBarWidth = 40
+const NavBarWidth = 59
 
 type NavBarModel struct {
-	width    int
-	viewMode ViewMode
+	width          int
+	viewMode       ViewMode
+	problemCount   int
+	duplicateCount int
+	reloadFailed   bool
 
 	navTransactionView key.Binding
 	navAccountView     key.Binding
 	navCategoryView    key.Binding
+	navNetWorthView    key.Binding
+	navTagView         key.Binding
 }
 
 type NavigationMsg struct {
@@ -38,6 +45,8 @@ func NewNavBarModel() NavBarModel {
 		navTransactionView: key.NewBinding(key.WithKeys("1")),
 		navAccountView:     key.NewBinding(key.WithKeys("2")),
 		navCategoryView:    key.NewBinding(key.WithKeys("3")),
+		navNetWorthView:    key.NewBinding(key.WithKeys("4")),
+		navTagView:         key.NewBinding(key.WithKeys("5")),
 	}
 }
 
@@ -45,10 +54,34 @@ func (m *NavBarModel) SetWidth(w int) {
 	m.width = w
 }
 
+// Set the number of import problems to point out in the title
+func (m *NavBarModel) SetProblemCount(count int) {
+	m.problemCount = count
+}
+
+// Set the number of possible duplicates to point out in the title
+func (m *NavBarModel) SetDuplicateCount(count int) {
+	m.duplicateCount = count
+}
+
+// Set whether the last reload failed, which the title points out instead of other notices
+func (m *NavBarModel) SetReloadFailed(failed bool) {
+	m.reloadFailed = failed
+}
+
 func (m *NavBarModel) ViewMode() ViewMode {
 	return m.viewMode
 }
 
+// Switch to the view, e.g. when drilling down from another view
+func (m *NavBarModel) SetViewMode(viewMode ViewMode) tea.Cmd {
+	if m.viewMode == viewMode {
+		return nil
+	}
+	m.viewMode = viewMode
+	return m.sendNavMsg()
+}
+
 func (m NavBarModel) View() string {
 	var s strings.Builder
 	s.WriteString(fmt.Sprintf("%s %s", keyStyle.Render(m.navTransactionView.Keys()[0]), TransactionView))
@@ -56,9 +89,25 @@ func (m NavBarModel) View() string {
 	s.WriteString(fmt.Sprintf("%s %s", keyStyle.Render(m.navAccountView.Keys()[0]), AccountView))
 	s.WriteString(" ")
 	s.WriteString(fmt.Sprintf("%s %s", keyStyle.Render(m.navCategoryView.Keys()[0]), CategoryView))
+	s.WriteString(" ")
+	s.WriteString(fmt.Sprintf("%s %s", keyStyle.Render(m.navNetWorthView.Keys()[0]), NetWorthView))
+	s.WriteString(" ")
+	s.WriteString(fmt.Sprintf("%s %s", keyStyle.Render(m.navTagView.Keys()[0]), TagView))
 
+	title := fmt.Sprintf("View: %s", m.viewMode)
+	// Shorter notices when both are shown, to fit in the title
+	switch {
+	case m.reloadFailed:
+		title += " | reload failed, see log"
+	case m.problemCount > 0 && m.duplicateCount > 0:
+		title += fmt.Sprintf(" | %d problems (!) | %d dups (D)", m.problemCount, m.duplicateCount)
+	case m.problemCount > 0:
+		title += fmt.Sprintf(" | %d import problems (!)", m.problemCount)
+	case m.duplicateCount > 0:
+		title += fmt.Sprintf(" | %d duplicates (D)", m.duplicateCount)
+	}
 	style := lipgloss.NewStyle().
-		Border(getRoundedBorderWithTitle(fmt.Sprintf("View: %s", m.viewMode), m.width)).
+		Border(getRoundedBorderWithTitle(title, m.width)).
 		BorderForeground(borderColor).
 		Padding(0, 1).
 		Margin(1, 0, 0).
@@ -89,6 +138,18 @@ func (m NavBarModel) Update(msg tea.Msg) (NavBarModel, tea.Cmd) {
 			}
 			m.viewMode = CategoryView
 			cmd = m.sendNavMsg()
+		case key.Matches(msg, m.navNetWorthView):
+			if m.viewMode == NetWorthView {
+				break
+			}
+			m.viewMode = NetWorthView
+			cmd = m.sendNavMsg()
+		case key.Matches(msg, m.navTagView):
+			if m.viewMode == TagView {
+				break
+			}
+			m.viewMode = TagView
+			cmd = m.sendNavMsg()
 		}
 	}
 	return m, cmd
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"cashd/internal/data"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ProblemsModel lists the records skipped while importing data
+type ProblemsModel struct {
+	problems []*data.ParseError
+	visible  bool
+	viewport viewport.Model
+	width    int
+	height   int
+}
+
+func NewProblemsModel() ProblemsModel {
+	return ProblemsModel{
+		viewport: viewport.New(0, 0),
+	}
+}
+
+func (m *ProblemsModel) SetProblems(problems []*data.ParseError) {
+	m.problems = problems
+	m.updateContent()
+}
+
+func (m *ProblemsModel) Count() int {
+	return len(m.problems)
+}
+
+// Toggle the panel, it is never shown without problems
+func (m *ProblemsModel) ToggleVisibility() {
+	m.visible = !m.visible && len(m.problems) > 0
+}
+
+func (m *ProblemsModel) Visible() bool {
+	return m.visible
+}
+
+func (m *ProblemsModel) SetDimensions(width, height int) {
+	m.width = width
+	m.height = height
+	m.viewport.Width = max(0, width-2*hPadding)
+	m.viewport.Height = max(0, height-2*vPadding)
+	m.updateContent()
+}
+
+func (m *ProblemsModel) updateContent() {
+	var s strings.Builder
+	for i, p := range m.problems {
+		if i > 0 {
+			s.WriteString("\n")
+		}
+		s.WriteString(fmt.Sprintf("%s:%d", keyStyle.Render(p.File), p.Line))
+		if p.Column != "" {
+			s.WriteString(fmt.Sprintf(" %s %q", p.Column, p.Value))
+		}
+		s.WriteString(fmt.Sprintf(": %v", p.Err))
+	}
+	// Wrap long problems instead of cutting them off
+	m.viewport.SetContent(lipgloss.NewStyle().Width(m.viewport.Width).Render(s.String()))
+}
+
+func (m ProblemsModel) Update(msg tea.Msg) (ProblemsModel, tea.Cmd) {
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m ProblemsModel) View() string {
+	title := fmt.Sprintf("Import Problems: %d records skipped", len(m.problems))
+	return lipgloss.NewStyle().
+		Border(getRoundedBorderWithTitle(title, m.width)).
+		BorderForeground(borderColor).
+		Width(m.width).
+		Height(m.height).
+		Padding(vPadding, hPadding).
+		Render(m.viewport.View())
+}
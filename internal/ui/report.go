@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"cashd/internal/data"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"time"
+)
+
+var reportTableConfigs = map[string]tableConfig{
+	TxnTableName:      transactionTableConfig,
+	AccountTableName:  accountTableConfig,
+	CategoryTableName: categoryTableConfig,
+	TagTableName:      tagTableConfig,
+}
+
+// ReportTable returns the column names and rows of a table in its default order, for reports outside of the TUI
+// Values are string, int, data.Money or time.Time, and nil when there is no value. Symbol columns are left out.
+func ReportTable(name string, transactions []*data.Transaction, ctx TableContext) ([]string, [][]any) {
+	config, ok := reportTableConfigs[name]
+	if !ok {
+		panic(fmt.Sprintf("unexpected table name: %s", name))
+	}
+
+	columns := []column{}
+	header := []string{}
+	for _, col := range config.columns {
+		// Only symbol columns are not sortable
+		if col.isSortable() {
+			columns = append(columns, col)
+			header = append(header, col.String())
+		}
+	}
+
+	rows := [][]any{}
+	for _, item := range config.dataProvider(transactions, ctx)(config.defaultSortColumn, config.defaultSortDir) {
+		row := make([]any, len(columns))
+		for i, col := range columns {
+			value := col.getColumnData(item)
+			if v, ok := value.(sortableValue); ok {
+				value = v.reportValue()
+			}
+			row[i] = value
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+// ExportTransactions writes the transactions to a CSV file with the columns of the transactions report
+func ExportTransactions(file string, transactions []*data.Transaction) error {
+	header, rows := ReportTable(TxnTableName, transactions, TableContext{})
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("failed to export transactions: %w", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to export transactions: %w", err)
+	}
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			switch v := value.(type) {
+			case nil:
+			case data.Money:
+				record[i] = v.String()
+			case time.Time:
+				record[i] = v.Format(time.DateOnly)
+			default:
+				record[i] = fmt.Sprint(v)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return fmt.Errorf("failed to export transactions: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to export transactions: %w", err)
+	}
+	return nil
+}
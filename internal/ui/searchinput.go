@@ -21,6 +21,9 @@ type SearchInputModel struct {
 	input     textinput.Model
 	nameInput textinput.Model
 	table     table.Model
+	// The last query that parsed, kept while the input has a malformed one
+	query  *data.Query
+	errMsg string
 
 	cancel       key.Binding
 	enter        key.Binding
@@ -47,6 +50,7 @@ func NewSearchInputModel() SearchInputModel {
 	return SearchInputModel{
 		input:     input,
 		nameInput: nameInput,
+		query:     &data.Query{},
 		table: table.New(
 			table.WithFocused(true),
 			table.WithHeight(5),
@@ -105,11 +109,39 @@ func (m *SearchInputModel) Value() string {
 	return m.input.Value()
 }
 
+// The query transactions are searched with
+func (m *SearchInputModel) Query() *data.Query {
+	return m.query
+}
+
+// Search for the query, e.g. to show the transactions behind a row of another view
+func (m *SearchInputModel) SetValue(query string) tea.Cmd {
+	m.input.SetValue(query)
+	if !m.parse() {
+		m.Focus()
+		return nil
+	}
+	return m.sendSearchMsg()
+}
+
 func (m *SearchInputModel) Clear() tea.Cmd {
 	m.input.SetValue("")
+	m.parse()
 	return m.sendSearchMsg()
 }
 
+// Parse the input, a malformed query leaves the previous query in place and shows why it failed
+func (m *SearchInputModel) parse() bool {
+	query, err := data.ParseQuery(m.input.Value())
+	if err != nil {
+		m.errMsg = err.Error()
+		return false
+	}
+	m.query = query
+	m.errMsg = ""
+	return true
+}
+
 func (m SearchInputModel) View() string {
 	var s string
 	if m.showNameInput {
@@ -117,6 +149,9 @@ func (m SearchInputModel) View() string {
 	} else {
 		s = lipgloss.JoinHorizontal(lipgloss.Top, m.input.View(), m.renderHelp())
 	}
+	if m.errMsg != "" {
+		s = lipgloss.JoinVertical(lipgloss.Left, s, expenseStyle.Render("   "+m.errMsg))
+	}
 	s = baseStyle.Width(m.width).Render(s)
 
 	if m.showTable {
@@ -180,6 +215,9 @@ func (m *SearchInputModel) handleSearchInputKeys(msg tea.KeyMsg) tea.Cmd {
 		m.input.SetValue("")
 		fallthrough
 	case key.Matches(msg, m.enter):
+		if !m.parse() {
+			break
+		}
 		m.Blur()
 		cmd = m.sendSearchMsg()
 	case key.Matches(msg, m.saveSearch):
@@ -192,7 +230,12 @@ func (m *SearchInputModel) handleSearchInputKeys(msg tea.KeyMsg) tea.Cmd {
 		m.refreshSavedSearch()
 		m.showTable = true
 	default:
+		value := m.input.Value()
 		m.input, cmd = m.input.Update(msg)
+		if m.input.Value() != value {
+			// The error is about the query before the edit
+			m.errMsg = ""
+		}
 	}
 	return cmd
 }
@@ -233,9 +276,15 @@ func (m *SearchInputModel) handleTableKeys(msg tea.KeyMsg) tea.Cmd {
 			name := selectedRow[0]
 			query := selectedRow[1]
 			m.input.SetValue(query)
-			m.Blur()
 			data.AddOrUpdateSavedSearch(name, query) // Update timestamp
 			m.refreshSavedSearch()
+			if !m.parse() {
+				// Fix the saved query in the input
+				m.showTable = false
+				m.input.Focus()
+				break
+			}
+			m.Blur()
 			cmd = m.sendSearchMsg()
 		}
 	case key.Matches(msg, m.cancel):
@@ -2,7 +2,9 @@ package ui
 
 import (
 	"cashd/internal/data"
+	"cashd/internal/date"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -32,10 +34,46 @@ const (
 type tableDataSorter func(sortCol column, sortDir sortDirection) []any
 
 // tableDataProvider is a function that takes transactions as input, and return a TableDataSorter
-type tableDataProvider func(transactions []*data.Transaction) tableDataSorter
+type tableDataProvider func(transactions []*data.Transaction, ctx TableContext) tableDataSorter
 
-// Return a unique string as the row's id
-type rowIdentifier func(table.Row) string
+// TableContext is what tables show besides the transactions
+type TableContext struct {
+	// Balance of each account at the end of the selected date range
+	Balances []data.Balance
+	// Budget of each category for the selected date range, parents without a budget add up their descendants
+	Budgets map[string]data.Money
+}
+
+func NewTableContext(balances *data.BalanceHistory, budgets map[string]data.Budget, inc date.Increment, start, end time.Time) TableContext {
+	inRange := map[string]data.Money{}
+	for category, b := range budgets {
+		inRange[category] = b.InDateRange(inc, start, end)
+	}
+	return TableContext{
+		Balances: balances.BalancesBefore(end),
+		Budgets:  data.RollUpBudgets(inRange),
+	}
+}
+
+// sortableValue is column data that has its own formatting and ordering
+type sortableValue interface {
+	String() string
+	Less(other sortableValue) bool
+	// Value in reports, see ReportTable
+	reportValue() any
+}
+
+// Return a unique string as the id of the row showing the table data
+type rowIdentifier func(any) string
+
+// treeNode is table data shown as a tree, each node is listed under its parent and sorted among its siblings
+type treeNode interface {
+	nodeId() string
+	// Empty for top-level nodes
+	parentId() string
+	// Shown in the tree column instead of the column data, indented by the depth of the node
+	label() string
+}
 
 type tableConfig struct {
 	columns           []column
@@ -43,6 +81,10 @@ type tableConfig struct {
 	rowId             rowIdentifier
 	defaultSortColumn column
 	defaultSortDir    sortDirection
+	// Column showing the tree, nil for flat tables, the table data must implement treeNode
+	treeColumn column
+	// Rows can be marked for bulk actions, marks are shown in a column before the others
+	multiSelect bool
 }
 
 type TableSelectionChangedMsg struct {
@@ -50,6 +92,14 @@ type TableSelectionChangedMsg struct {
 	Selected  string
 }
 
+// TableMarksChangedMsg is sent when rows of a multi-select table are marked or unmarked
+type TableMarksChangedMsg struct {
+	TableName string
+}
+
+// Shown in the mark column of marked rows
+const markSymbol = "✓"
+
 type SortableTableModel struct {
 	name          string
 	columns       []column
@@ -59,10 +109,29 @@ type SortableTableModel struct {
 	sortColumn    column
 	sortDirection sortDirection
 	table         table.Model
+	// Table data of each row
+	items []any
+
+	treeColumn column
+	// Levels of the tree expanded by default, 0 for all levels
+	treeDepth    int
+	maxTreeDepth int
+	// Nodes expanded or collapsed by the user, reset when the depth changes
+	toggled map[string]bool
+	parents map[string]string
+
+	multiSelect bool
+	// Ids of the marked rows, rows no longer in the table are unmarked
+	marked map[string]bool
 
 	sortNext    key.Binding
 	sortPrev    key.Binding
 	reverseSort key.Binding
+	toggleNode  key.Binding
+	deeper      key.Binding
+	shallower   key.Binding
+	toggleMark  key.Binding
+	markAll     key.Binding
 }
 
 func newSortableTableModel(name string, config tableConfig) SortableTableModel {
@@ -73,10 +142,20 @@ func newSortableTableModel(name string, config tableConfig) SortableTableModel {
 		rowId:         config.rowId,
 		sortColumn:    config.defaultSortColumn,
 		sortDirection: config.defaultSortDir,
+		treeColumn:    config.treeColumn,
+		toggled:       map[string]bool{},
+		parents:       map[string]string{},
+		multiSelect:   config.multiSelect,
+		marked:        map[string]bool{},
 
 		sortNext:    key.NewBinding(key.WithKeys("s")),
 		sortPrev:    key.NewBinding(key.WithKeys("S")),
 		reverseSort: key.NewBinding(key.WithKeys("r")),
+		toggleNode:  key.NewBinding(key.WithKeys("e")),
+		deeper:      key.NewBinding(key.WithKeys("+", "=")),
+		shallower:   key.NewBinding(key.WithKeys("-")),
+		toggleMark:  key.NewBinding(key.WithKeys(" ")),
+		markAll:     key.NewBinding(key.WithKeys("*")),
 	}
 	m.table = table.New(
 		table.WithColumns(m.getTableColumns()),
@@ -88,6 +167,9 @@ func newSortableTableModel(name string, config tableConfig) SortableTableModel {
 
 func (m *SortableTableModel) getTableColumns() []table.Column {
 	tableCols := []table.Column{}
+	if m.multiSelect {
+		tableCols = append(tableCols, table.Column{Title: " ", Width: markColWidth})
+	}
 	for _, col := range m.columns {
 		title := col.String()
 		width := col.width()
@@ -118,6 +200,23 @@ func (m SortableTableModel) Update(msg tea.Msg) (SortableTableModel, tea.Cmd) {
 			m.sortPrevColumn()
 		case key.Matches(msg, m.reverseSort):
 			m.reverseSortDir()
+		case m.treeColumn != nil && key.Matches(msg, m.toggleNode):
+			m.toggleSelectedNode()
+		case m.treeColumn != nil && key.Matches(msg, m.deeper):
+			m.changeTreeDepth(1)
+		case m.treeColumn != nil && key.Matches(msg, m.shallower):
+			m.changeTreeDepth(-1)
+		case m.multiSelect && key.Matches(msg, m.toggleMark):
+			// Space would page down the table, the cursor moves to the next row instead
+			m.toggleSelectedMark()
+			m.table.MoveDown(1)
+			if m.Selected() != selected {
+				cmd = m.sendSelectionChangedMsg()
+			}
+			return m, tea.Batch(m.sendMarksChangedMsg(), cmd)
+		case m.multiSelect && key.Matches(msg, m.markAll):
+			m.toggleAllMarks()
+			return m, m.sendMarksChangedMsg()
 		}
 	}
 	m.table, _ = m.table.Update(msg)
@@ -128,13 +227,21 @@ func (m SortableTableModel) Update(msg tea.Msg) (SortableTableModel, tea.Cmd) {
 }
 
 func (m *SortableTableModel) Selected() string {
-	if row := m.table.SelectedRow(); row != nil && m.rowId != nil {
-		return m.rowId(row)
+	if cursor := m.table.Cursor(); cursor >= 0 && cursor < len(m.items) && m.rowId != nil {
+		return m.rowId(m.items[cursor])
 	} else {
 		return ""
 	}
 }
 
+// Return the table data of the selected row, nil if the table is empty
+func (m *SortableTableModel) SelectedItem() any {
+	if cursor := m.table.Cursor(); cursor >= 0 && cursor < len(m.items) {
+		return m.items[cursor]
+	}
+	return nil
+}
+
 func (m *SortableTableModel) sendSelectionChangedMsg() tea.Cmd {
 	return func() tea.Msg {
 		return TableSelectionChangedMsg{
@@ -144,6 +251,61 @@ func (m *SortableTableModel) sendSelectionChangedMsg() tea.Cmd {
 	}
 }
 
+func (m *SortableTableModel) sendMarksChangedMsg() tea.Cmd {
+	return func() tea.Msg {
+		return TableMarksChangedMsg{TableName: m.name}
+	}
+}
+
+// Return the table data of the marked rows in table order
+func (m *SortableTableModel) Marked() []any {
+	marked := []any{}
+	for _, item := range m.items {
+		if m.marked[m.rowId(item)] {
+			marked = append(marked, item)
+		}
+	}
+	return marked
+}
+
+func (m *SortableTableModel) MarkedCount() int {
+	return len(m.marked)
+}
+
+func (m *SortableTableModel) ClearMarks() tea.Cmd {
+	if len(m.marked) == 0 {
+		return nil
+	}
+	clear(m.marked)
+	m.updateRows()
+	return m.sendMarksChangedMsg()
+}
+
+func (m *SortableTableModel) toggleSelectedMark() {
+	id := m.Selected()
+	if id == "" {
+		return
+	}
+	if m.marked[id] {
+		delete(m.marked, id)
+	} else {
+		m.marked[id] = true
+	}
+	m.updateRows()
+}
+
+// Mark all rows, e.g. all transactions matching the search, or unmark all if they are all marked already
+func (m *SortableTableModel) toggleAllMarks() {
+	if len(m.marked) == len(m.items) {
+		clear(m.marked)
+	} else {
+		for _, item := range m.items {
+			m.marked[m.rowId(item)] = true
+		}
+	}
+	m.updateRows()
+}
+
 func (m *SortableTableModel) sortNextColumn() {
 	newCol := m.sortColumn.nextColumn()
 	for !newCol.isSortable() {
@@ -185,14 +347,35 @@ func (m *SortableTableModel) SetDimensions(width, height int) {
 	m.table.SetHeight(height)
 }
 
-func (m *SortableTableModel) SetTransactions(transactions []*data.Transaction) tea.Cmd {
+func (m *SortableTableModel) SetTransactions(transactions []*data.Transaction, ctx TableContext) tea.Cmd {
 	selected := m.Selected()
-	m.dataSorter = m.dataProvider(transactions)
+	marked := len(m.marked)
+	m.dataSorter = m.dataProvider(transactions, ctx)
 	m.updateRows()
+	m.selectRow(selected)
+	cmds := []tea.Cmd{}
 	if m.Selected() != selected {
-		return m.sendSelectionChangedMsg()
-	} else {
-		return nil
+		cmds = append(cmds, m.sendSelectionChangedMsg())
+	}
+	if len(m.marked) != marked {
+		cmds = append(cmds, m.sendMarksChangedMsg())
+	}
+	return tea.Batch(cmds...)
+}
+
+// Move the cursor to the row with the id, e.g. when rows are added before it, the cursor stays if there is none
+// A node hidden in a collapsed tree selects its closest shown ancestor instead
+func (m *SortableTableModel) selectRow(id string) {
+	if m.rowId == nil || id == "" || m.Selected() == id {
+		return
+	}
+	for ; id != ""; id = m.parents[id] {
+		for i, item := range m.items {
+			if m.rowId(item) == id {
+				m.table.SetCursor(i)
+				return
+			}
+		}
 	}
 }
 
@@ -200,7 +383,129 @@ func (m *SortableTableModel) updateRows() {
 	if m.dataSorter == nil {
 		return
 	}
-	m.table.SetRows(getTableRows(m.columns, m.dataSorter(m.sortColumn, m.sortDirection)))
+	m.items = m.dataSorter(m.sortColumn, m.sortDirection)
+	if m.treeColumn == nil {
+		m.table.SetRows(m.markRows(getTableRows(m.columns, m.items)))
+		return
+	}
+
+	var labels []string
+	m.items, labels = m.arrangeTree(m.items)
+	rows := getTableRows(m.columns, m.items)
+	for i, row := range rows {
+		row[m.treeColumn.index()] = labels[i]
+	}
+	m.table.SetRows(m.markRows(rows))
+}
+
+// Add the mark column to the rows of a multi-select table, and unmark rows no longer in the table
+func (m *SortableTableModel) markRows(rows []table.Row) []table.Row {
+	if !m.multiSelect {
+		return rows
+	}
+	ids := make(map[string]bool, len(m.items))
+	for i, item := range m.items {
+		id := m.rowId(item)
+		ids[id] = true
+		mark := " "
+		if m.marked[id] {
+			mark = markSymbol
+		}
+		rows[i] = append(table.Row{mark}, rows[i]...)
+	}
+	for id := range m.marked {
+		if !ids[id] {
+			delete(m.marked, id)
+		}
+	}
+	return rows
+}
+
+// Order sorted nodes as a tree without the children of collapsed nodes, and return the label of each node
+func (m *SortableTableModel) arrangeTree(nodes []any) ([]any, []string) {
+	m.parents = map[string]string{}
+	for _, n := range nodes {
+		node := n.(treeNode)
+		m.parents[node.nodeId()] = node.parentId()
+	}
+	children := map[string][]treeNode{}
+	m.maxTreeDepth = 0
+	for _, n := range nodes {
+		node := n.(treeNode)
+		parent := node.parentId()
+		if _, exist := m.parents[parent]; !exist {
+			// Shown at the top when the parent is not in the table
+			parent = ""
+		}
+		children[parent] = append(children[parent], node)
+		m.maxTreeDepth = max(m.maxTreeDepth, m.nodeDepth(node.nodeId())+1)
+	}
+
+	arranged := []any{}
+	labels := []string{}
+	var visit func(parent string, depth int)
+	visit = func(parent string, depth int) {
+		for _, node := range children[parent] {
+			id := node.nodeId()
+			hasChildren := len(children[id]) > 0
+			expanded := hasChildren && m.expanded(id, depth)
+			marker := "  "
+			if expanded {
+				marker = "▾ "
+			} else if hasChildren {
+				marker = "▸ "
+			}
+			arranged = append(arranged, node)
+			labels = append(labels, strings.Repeat("  ", depth)+marker+node.label())
+			if expanded {
+				visit(id, depth+1)
+			}
+		}
+	}
+	visit("", 0)
+	return arranged, labels
+}
+
+// Return the number of ancestors of the node in the table
+func (m *SortableTableModel) nodeDepth(id string) int {
+	depth := 0
+	for parent, exist := m.parents[id]; exist && parent != ""; parent, exist = m.parents[parent] {
+		depth++
+	}
+	return depth
+}
+
+func (m *SortableTableModel) expanded(id string, depth int) bool {
+	if expanded, exist := m.toggled[id]; exist {
+		return expanded
+	}
+	return m.treeDepth == 0 || depth+1 < m.treeDepth
+}
+
+func (m *SortableTableModel) toggleSelectedNode() {
+	id := m.Selected()
+	if id == "" {
+		return
+	}
+	m.toggled[id] = !m.expanded(id, m.nodeDepth(id))
+	m.updateRows()
+}
+
+// Expand or collapse all nodes by levels, going deeper than the deepest level expands all
+func (m *SortableTableModel) changeTreeDepth(levels int) {
+	selected := m.Selected()
+	depth := m.treeDepth
+	if depth == 0 {
+		depth = m.maxTreeDepth
+	}
+	depth = max(1, depth+levels)
+	if depth >= m.maxTreeDepth {
+		depth = 0
+	}
+	m.treeDepth = depth
+	clear(m.toggled)
+	m.updateRows()
+	m.selectRow(selected)
 }
 
 func getTableRows(cols []column, tableData []any) []table.Row {
@@ -214,10 +519,12 @@ func getTableRows(cols []column, tableData []any) []table.Row {
 				formattedColData = colData
 			case int:
 				formattedColData = fmt.Sprintf("%d", colData)
-			case float64:
-				formattedColData = data.FormatMoney(colData)
+			case data.Money:
+				formattedColData = colData.Format()
 			case time.Time:
 				formattedColData = colData.Format(time.DateOnly)
+			case sortableValue:
+				formattedColData = colData.String()
 			default:
 				panic(fmt.Sprintf("unexpected table data type: %v", colData))
 			}
@@ -238,10 +545,12 @@ func compareAny(a, b any, sortDir sortDirection) bool {
 		inOrder = a.(string) < b.(string)
 	case int:
 		inOrder = a.(int) < b.(int)
-	case float64:
-		inOrder = a.(float64) < b.(float64)
+	case data.Money:
+		inOrder = a.(data.Money) < b.(data.Money)
 	case time.Time:
 		inOrder = a.(time.Time).Before(b.(time.Time))
+	case sortableValue:
+		inOrder = a.(sortableValue).Less(b.(sortableValue))
 	default:
 		panic(fmt.Sprintf("unexpected table data type: %v", a))
 	}
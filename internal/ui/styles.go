@@ -12,6 +12,7 @@ const (
 
 const (
 	symbolColWidth      = 1
+	markColWidth        = 1
 	dateColWidth        = 12
 	typeColWidth        = 8
 	accountTypeColWidth = 12
@@ -20,6 +21,10 @@ const (
 	descColWidth        = 20
 	amountColWidth      = 12
 	numberColWidth      = 8
+	progressColWidth    = 16
+	sourceColWidth      = 9
+	ruleColWidth        = 12
+	tagColWidth         = 20
 )
 
 var (
@@ -59,10 +64,14 @@ var (
 )
 
 var (
-	tsChartIncomeLineStyle  = incomeStyle
-	tsChartExpenseLineStyle = expenseStyle
-	tsChartAxisStyle        = lipgloss.NewStyle().Foreground(highlightColor)
-	tsChartLabelStyle       = lipgloss.NewStyle().Foreground(borderColor)
+	tsChartIncomeLineStyle      = incomeStyle
+	tsChartExpenseLineStyle     = expenseStyle
+	tsChartAssetsLineStyle      = incomeStyle
+	tsChartLiabilitiesLineStyle = expenseStyle
+	tsChartNetWorthLineStyle    = lipgloss.NewStyle().Foreground(chartColor2)
+	tsChartBudgetLineStyle      = lipgloss.NewStyle().Foreground(chartColor5)
+	tsChartAxisStyle            = lipgloss.NewStyle().Foreground(highlightColor)
+	tsChartLabelStyle           = lipgloss.NewStyle().Foreground(borderColor)
 )
 
 func getTableStyle() table.Styles {
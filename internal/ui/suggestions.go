@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"cashd/internal/data"
+	"sort"
+)
+
+// Values of the loaded transactions to autocomplete inputs with, most used first
+type suggestions struct {
+	accounts     []string
+	categories   []string
+	descriptions []string
+	tags         []string
+}
+
+func newSuggestions(transactions []*data.Transaction) suggestions {
+	accounts := map[string]int{}
+	categories := map[string]int{}
+	descriptions := map[string]int{}
+	tags := map[string]int{}
+	for _, t := range transactions {
+		accounts[t.Account]++
+		if t.IsTransfer() {
+			accounts[t.ToAccount]++
+		}
+		for _, s := range t.CategorySplits() {
+			categories[s.Category]++
+		}
+		if t.Description != "" {
+			descriptions[t.Description]++
+		}
+		for _, tag := range t.Tags {
+			tags[tag]++
+		}
+	}
+	return suggestions{
+		accounts:     byCount(accounts),
+		categories:   byCount(categories),
+		descriptions: byCount(descriptions),
+		tags:         byCount(tags),
+	}
+}
+
+// Return the keys from the most to the least counted
+func byCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
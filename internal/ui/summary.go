@@ -19,18 +19,23 @@ const (
 
 type summaryEntry struct {
 	key   string
-	value float64
+	value data.Money
 }
 
 type SummaryModel struct {
 	width  int
 	height int
 
-	transactions  []*data.Transaction
-	incomeTxnNum  int
-	expenseTxnNum int
-	totalIncome   float64
-	totalExpense  float64
+	transactions   []*data.Transaction
+	incomeTxnNum   int
+	expenseTxnNum  int
+	transferTxnNum int
+	totalIncome    data.Money
+	totalExpense   data.Money
+	totalTransfers data.Money
+	// Rows marked in the transaction table, income adds to their total and expenses subtract from it
+	markedTxnNum int
+	markedTotal  data.Money
 
 	topIncomeCategories  []summaryEntry
 	topIncomeAccounts    []summaryEntry
@@ -59,15 +64,28 @@ func (m *SummaryModel) SetTransactions(transactions []*data.Transaction) {
 
 	m.incomeTxnNum = 0
 	m.expenseTxnNum = 0
+	m.transferTxnNum = 0
 	m.totalIncome = 0
 	m.totalExpense = 0
+	m.totalTransfers = 0
 	for _, tx := range m.transactions {
-		if tx.Type == data.Income {
+		switch tx.Type {
+		case data.Income:
 			m.incomeTxnNum++
-			m.totalIncome += tx.Amount
-		} else {
+		case data.Expense:
 			m.expenseTxnNum++
-			m.totalExpense += tx.Amount
+		case data.Transfer:
+			// Transfers are neither income nor expense
+			m.transferTxnNum++
+			m.totalTransfers += tx.Amount
+		}
+		for _, split := range tx.CategorySplits() {
+			switch split.Type {
+			case data.Income:
+				m.totalIncome += split.Amount
+			case data.Expense:
+				m.totalExpense += split.Amount
+			}
 		}
 	}
 
@@ -79,6 +97,21 @@ func (m *SummaryModel) SetTransactions(transactions []*data.Transaction) {
 	m.updateCharts()
 }
 
+func (m *SummaryModel) SetMarked(transactions []*data.Transaction) {
+	m.markedTxnNum = len(transactions)
+	m.markedTotal = 0
+	for _, tx := range transactions {
+		for _, split := range tx.CategorySplits() {
+			switch split.Type {
+			case data.Income:
+				m.markedTotal += split.Amount
+			case data.Expense:
+				m.markedTotal -= split.Amount
+			}
+		}
+	}
+}
+
 func (m *SummaryModel) updateCharts() {
 	m.incomeCategoryChart.Draw()
 	m.incomeAccountChart.Draw()
@@ -95,10 +128,17 @@ func (m *SummaryModel) resizeCharts() {
 
 func (m SummaryModel) View() string {
 	var s strings.Builder
+	if m.markedTxnNum > 0 {
+		s.WriteString(keyStyle.Render(fmt.Sprintf("Marked transactions: %d", m.markedTxnNum)) + "\n")
+		s.WriteString(keyStyle.Render(fmt.Sprintf("Marked total: %s", data.FormatReportingAmount(m.markedTotal))) + "\n\n")
+	}
 	s.WriteString(fmt.Sprintf("Income transactions: %d\n", m.incomeTxnNum))
 	s.WriteString(fmt.Sprintf("Expense transactions: %d\n", m.expenseTxnNum))
-	s.WriteString(fmt.Sprintf("Total income: $%s\n", data.FormatMoney(m.totalIncome)))
-	s.WriteString(fmt.Sprintf("Total expenses: $%s\n", data.FormatMoney(m.totalExpense)))
+	s.WriteString(fmt.Sprintf("Total income: %s\n", data.FormatReportingAmount(m.totalIncome)))
+	s.WriteString(fmt.Sprintf("Total expenses: %s\n", data.FormatReportingAmount(m.totalExpense)))
+	if m.transferTxnNum > 0 {
+		s.WriteString(fmt.Sprintf("Transfers: %s in %d transactions\n", data.FormatReportingAmount(m.totalTransfers), m.transferTxnNum))
+	}
 
 	if len(m.transactions) > 0 {
 		s.WriteString("\nTop income categories:\n")
@@ -124,10 +164,12 @@ func (m SummaryModel) View() string {
 }
 
 func (m SummaryModel) getTopCategories(txnType data.TransactionType) ([]summaryEntry, barchart.Model) {
-	expenseByCategory := make(map[string]float64)
+	expenseByCategory := make(map[string]data.Money)
 	for _, tx := range m.transactions {
-		if tx.Type == txnType {
-			expenseByCategory[tx.Category] += tx.Amount
+		for _, split := range tx.CategorySplits() {
+			if split.Type == txnType {
+				expenseByCategory[split.Category] += split.Amount
+			}
 		}
 	}
 
@@ -136,10 +178,12 @@ func (m SummaryModel) getTopCategories(txnType data.TransactionType) ([]summaryE
 }
 
 func (m SummaryModel) getTopAccounts(txnType data.TransactionType) ([]summaryEntry, barchart.Model) {
-	expenseByAccount := make(map[string]float64)
+	expenseByAccount := make(map[string]data.Money)
 	for _, tx := range m.transactions {
-		if tx.Type == txnType {
-			expenseByAccount[tx.Account] += tx.Amount
+		for _, split := range tx.CategorySplits() {
+			if split.Type == txnType {
+				expenseByAccount[tx.Account] += split.Amount
+			}
 		}
 	}
 
@@ -148,7 +192,7 @@ func (m SummaryModel) getTopAccounts(txnType data.TransactionType) ([]summaryEnt
 }
 
 // Sort by value in reverse order and keep the top 5 entries
-func sortAndTruncate(input map[string]float64) []summaryEntry {
+func sortAndTruncate(input map[string]data.Money) []summaryEntry {
 	var sorted []summaryEntry
 	for k, v := range input {
 		sorted = append(sorted, summaryEntry{k, v})
@@ -158,7 +202,7 @@ func sortAndTruncate(input map[string]float64) []summaryEntry {
 	})
 	if len(sorted) > maxSummaryEntries {
 		// Sum [maxSummaryEntries-1:] to be "Everything else"
-		sumOfRemaining := 0.0
+		var sumOfRemaining data.Money
 		for _, s := range sorted[maxSummaryEntries-1:] {
 			sumOfRemaining += s.value
 		}
@@ -178,7 +222,7 @@ func sortAndTruncate(input map[string]float64) []summaryEntry {
 func getBarChartModel(width int, data []summaryEntry) barchart.Model {
 	barValues := []barchart.BarValue{}
 	for i, item := range data {
-		barValues = append(barValues, barchart.BarValue{Name: item.key, Value: item.value, Style: barChartStyles[i]})
+		barValues = append(barValues, barchart.BarValue{Name: item.key, Value: item.value.Float64(), Style: barChartStyles[i]})
 	}
 	return barchart.New(
 		width,
@@ -193,10 +237,10 @@ func (m SummaryModel) renderSummarySection(entries []summaryEntry, chart barchar
 	var s strings.Builder
 	for i, item := range entries {
 		s.WriteString(fmt.Sprintf(
-			"%s %s: $%s\n",
+			"%s %s: %s\n",
 			barChartStyles[i].Render(string(runes.FullBlock)), // Bar legend
 			item.key,
-			data.FormatMoney(item.value),
+			data.FormatReportingAmount(item.value),
 		))
 	}
 	s.WriteString("\n")
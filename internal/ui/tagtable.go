@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"cashd/internal/data"
+	"sort"
+)
+
+type tagColumn int
+
+const (
+	tagColName tagColumn = iota
+	tagColNumTxns
+	tagColIncome
+	tagColExpense
+	tagColNet
+
+	totalNumTagColumns
+)
+
+func (c tagColumn) index() int {
+	return int(c)
+}
+
+func (c tagColumn) rightAligned() bool {
+	return c != tagColName
+}
+
+func (c tagColumn) isSortable() bool {
+	return true
+}
+
+func (c tagColumn) width() int {
+	return tagColWidthMap[c]
+}
+
+func (c tagColumn) nextColumn() column {
+	return column(tagColumn((int(c) + 1) % int(totalNumTagColumns)))
+}
+
+func (c tagColumn) prevColumn() column {
+	return column(tagColumn((int(c) - 1 + int(totalNumTagColumns)) % int(totalNumTagColumns)))
+}
+
+func (c tagColumn) getColumnData(a any) any {
+	switch tag := a.(*tagInfo); c {
+	case tagColName:
+		return tag.name
+	case tagColNumTxns:
+		return tag.numTxns
+	case tagColIncome:
+		return tag.income
+	case tagColExpense:
+		return tag.expense
+	case tagColNet:
+		return tag.income - tag.expense
+	default:
+		return ""
+	}
+}
+
+func (c tagColumn) String() string {
+	switch c {
+	case tagColName:
+		return "Tag"
+	case tagColNumTxns:
+		return "Txn #"
+	case tagColIncome:
+		return "Income"
+	case tagColExpense:
+		return "Expense"
+	case tagColNet:
+		return "Net"
+	default:
+		return "Unknown"
+	}
+}
+
+var tagColWidthMap = map[tagColumn]int{
+	tagColName:    tagColWidth,
+	tagColNumTxns: numberColWidth,
+	tagColIncome:  amountColWidth,
+	tagColExpense: amountColWidth,
+	tagColNet:     amountColWidth,
+}
+
+var TagTableWidth = func() int {
+	tableWidth := 0
+	for i := range totalNumTagColumns {
+		tableWidth += tagColWidthMap[tagColumn(i)] + 2
+	}
+	return tableWidth
+}()
+
+const TagTableName = "Tag"
+
+func NewTagTableModel() SortableTableModel {
+	return newSortableTableModel(TagTableName, tagTableConfig)
+}
+
+var tagTableConfig = tableConfig{
+	columns: func() []column {
+		cols := []column{}
+		for i := range int(totalNumTagColumns) {
+			cols = append(cols, column(tagColumn(i)))
+		}
+		return cols
+	}(),
+	dataProvider:      tagTableDataProvider,
+	rowId:             func(a any) string { return a.(*tagInfo).name },
+	defaultSortColumn: column(tagColName),
+	defaultSortDir:    sortAsc,
+}
+
+type tagInfo struct {
+	name    string
+	numTxns int
+	income  data.Money
+	expense data.Money
+}
+
+func tagTableDataProvider(transactions []*data.Transaction, _ TableContext) tableDataSorter {
+	tags := getTagInfo(transactions)
+	result := make([]any, len(tags))
+	for i, tag := range tags {
+		result[i] = tag
+	}
+
+	return func(sortCol column, sortDir sortDirection) []any {
+		sort.Slice(result, func(i, j int) bool {
+			return compareAny(sortCol.getColumnData(result[i]), sortCol.getColumnData(result[j]), sortDir)
+		})
+		return result
+	}
+}
+
+// Get tag-level stats by aggregating transactions, tags with a value are listed as tag:value
+// The whole transaction counts towards each of its tags, transfers are counted but have no income or expense
+func getTagInfo(transactions []*data.Transaction) []*tagInfo {
+	tagMap := make(map[string]*tagInfo)
+	for _, tx := range transactions {
+		for _, name := range tx.AllTags() {
+			tag, exist := tagMap[name]
+			if !exist {
+				tag = &tagInfo{name: name}
+				tagMap[name] = tag
+			}
+			tag.numTxns++
+			for _, split := range tx.CategorySplits() {
+				switch split.Type {
+				case data.Income:
+					tag.income += split.Amount
+				case data.Expense:
+					tag.expense += split.Amount
+				}
+			}
+		}
+	}
+
+	tags := []*tagInfo{}
+	for _, t := range tagMap {
+		tags = append(tags, t)
+	}
+	return tags
+}
@@ -5,6 +5,8 @@ import (
 	"cashd/internal/date"
 	"fmt"
 	"math"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/NimbleMarkets/ntcharts/canvas/runes"
@@ -16,8 +18,35 @@ import (
 type TsChartEntry struct {
 	Date    time.Time
 	Inc     date.Increment
-	Income  float64
-	Expense float64
+	Income  data.Money
+	Expense data.Money
+	// Balances at the end of the increment, liabilities are negative
+	Assets      data.Money
+	Liabilities data.Money
+	// Budget for the increment, 0 if there is no budget
+	Budget data.Money
+}
+
+// A line drawn in the time series chart
+type tsChartLine struct {
+	name  string
+	style lipgloss.Style
+	value func(*TsChartEntry) data.Money
+	// Optional lines are only drawn when they have non-zero values
+	optional bool
+}
+
+var incomeExpenseLines = []tsChartLine{
+	{string(data.Income), tsChartIncomeLineStyle, func(e *TsChartEntry) data.Money { return e.Income }, false},
+	{string(data.Expense), tsChartExpenseLineStyle, func(e *TsChartEntry) data.Money { return e.Expense }, false},
+	{"Budget", tsChartBudgetLineStyle, func(e *TsChartEntry) data.Money { return e.Budget }, true},
+}
+
+var netWorthLines = []tsChartLine{
+	{"Assets", tsChartAssetsLineStyle, func(e *TsChartEntry) data.Money { return e.Assets }, false},
+	// Show what is owed as a positive amount
+	{"Liabilities", tsChartLiabilitiesLineStyle, func(e *TsChartEntry) data.Money { return -e.Liabilities }, false},
+	{"Net Worth", tsChartNetWorthLineStyle, func(e *TsChartEntry) data.Money { return e.Assets + e.Liabilities }, false},
 }
 
 type TimeSeriesChartModel struct {
@@ -27,12 +56,20 @@ type TimeSeriesChartModel struct {
 	name    string
 	inc     date.Increment
 	entries []*TsChartEntry
+	lines   []tsChartLine // All lines of the chart
+	visible []tsChartLine // Lines drawn for the current entries
 
 	chart tschart.Model
 }
 
+// Chart of incomes and expenses
 func NewTimeSeriesChartModel() TimeSeriesChartModel {
-	return TimeSeriesChartModel{}
+	return TimeSeriesChartModel{lines: incomeExpenseLines}
+}
+
+// Chart of assets, liabilities and net worth
+func NewNetWorthChartModel() TimeSeriesChartModel {
+	return TimeSeriesChartModel{lines: netWorthLines}
 }
 
 func (m *TimeSeriesChartModel) SetDimension(width, height int) {
@@ -48,37 +85,47 @@ func (m *TimeSeriesChartModel) SetEntries(name string, entries []*TsChartEntry,
 	m.name = name
 	m.entries = entries
 	m.inc = inc
+	m.visible = []tsChartLine{}
+	for _, line := range m.lines {
+		if !line.optional || slices.ContainsFunc(entries, func(e *TsChartEntry) bool { return line.value(e) != 0 }) {
+			m.visible = append(m.visible, line)
+		}
+	}
 	m.redraw()
 }
 
-// Draw a timeseries chart with 2 lines for incomes and expenses each
+// Draw a timeseries chart with a line for each of the chart's values
 func (m *TimeSeriesChartModel) redraw() {
 	if len(m.entries) == 0 || m.width == 0 || m.height == 0 {
 		return
 	}
 
-	var maxValue float64
+	// The Y axis starts from 0 unless there are negative values, e.g. a negative net worth
+	var minValue, maxValue data.Money
 	for _, entry := range m.entries {
-		maxValue = max(maxValue, entry.Income)
-		maxValue = max(maxValue, entry.Expense)
+		for _, line := range m.visible {
+			minValue = min(minValue, line.value(entry))
+			maxValue = max(maxValue, line.value(entry))
+		}
 	}
 
 	// Create a new chart on data set change, not worth reusing the model
-	m.chart = tschart.New(
-		m.width,
-		m.height,
-		tschart.WithYRange(0, maxValue),
+	options := []tschart.Option{
+		tschart.WithYRange(minValue.Float64(), maxValue.Float64()),
 		tschart.WithAxesStyles(tsChartAxisStyle, tsChartLabelStyle),
-		tschart.WithDataSetStyle(string(data.Income), tsChartIncomeLineStyle),   // Income style
-		tschart.WithDataSetStyle(string(data.Expense), tsChartExpenseLineStyle), // Expense style
 		tschart.WithXLabelFormatter(dateLabelFormatter(m.inc)),
 		tschart.WithYLabelFormatter(moneyAmountFormatter),
-	)
+	}
+	for _, line := range m.visible {
+		options = append(options, tschart.WithDataSetStyle(line.name, line.style))
+	}
+	m.chart = tschart.New(m.width, m.height, options...)
 
 	// Push data to the respective datasets
 	for _, entry := range m.entries {
-		m.chart.PushDataSet(string(data.Income), tschart.TimePoint{Time: entry.Date, Value: entry.Income})
-		m.chart.PushDataSet(string(data.Expense), tschart.TimePoint{Time: entry.Date, Value: entry.Expense})
+		for _, line := range m.visible {
+			m.chart.PushDataSet(line.name, tschart.TimePoint{Time: entry.Date, Value: line.value(entry).Float64()})
+		}
 	}
 	// Limit the X range
 	m.chart.SetViewTimeRange(m.entries[0].Date, m.entries[len(m.entries)-1].Date)
@@ -95,17 +142,15 @@ func (m TimeSeriesChartModel) View() string {
 }
 
 func (m TimeSeriesChartModel) renderLegend() string {
-	return fmt.Sprintf(
-		"\n%s %s    %s %s\n\n",
-		tsChartIncomeLineStyle.Render(string(runes.FullBlock)),
-		data.Income,
-		tsChartExpenseLineStyle.Render(string(runes.FullBlock)),
-		data.Expense,
-	)
+	legends := []string{}
+	for _, line := range m.visible {
+		legends = append(legends, fmt.Sprintf("%s %s", line.style.Render(string(runes.FullBlock)), line.name))
+	}
+	return "\n" + strings.Join(legends, "    ") + "\n\n"
 }
 
 func moneyAmountFormatter(i int, v float64) string {
-	return data.FormatMoneyInteger(math.Round(v/10) * 10)
+	return data.MoneyFromFloat(math.Round(v/10) * 10).FormatInteger()
 }
 
 func dateLabelFormatter(inc date.Increment) linechart.LabelFormatter {
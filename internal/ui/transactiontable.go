@@ -2,7 +2,9 @@ package ui
 
 import (
 	"cashd/internal/data"
+	"fmt"
 	"sort"
+	"time"
 )
 
 type txnColumn int
@@ -15,6 +17,8 @@ const (
 	txnColCategory
 	txnColDesc
 	txnColAmount
+	txnColSource
+	txnColRule
 
 	totalNumTxnColumns
 )
@@ -52,6 +56,9 @@ func (c txnColumn) getColumnData(a any) any {
 	case txnColType:
 		return string(txn.Type)
 	case txnColAccount:
+		if txn.IsTransfer() {
+			return txn.Account + " → " + txn.ToAccount
+		}
 		return txn.Account
 	case txnColCategory:
 		return txn.Category
@@ -59,6 +66,10 @@ func (c txnColumn) getColumnData(a any) any {
 		return txn.Description
 	case txnColAmount:
 		return txn.Amount
+	case txnColSource:
+		return txn.Source
+	case txnColRule:
+		return txn.Rule
 	default:
 		return ""
 	}
@@ -80,6 +91,10 @@ func (c txnColumn) String() string {
 		return "Description"
 	case txnColAmount:
 		return "Amount"
+	case txnColSource:
+		return "Source"
+	case txnColRule:
+		return "Rule"
 	default:
 		return "Unknown"
 	}
@@ -93,10 +108,13 @@ var txnColWidthMap = map[txnColumn]int{
 	txnColCategory: categoryColWidth,
 	txnColDesc:     descColWidth,
 	txnColAmount:   amountColWidth,
+	txnColSource:   sourceColWidth,
+	txnColRule:     ruleColWidth,
 }
 
 var TxnTableWidth = func() int {
-	tableWidth := 0
+	// Transactions can be marked
+	tableWidth := markColWidth + 2
 	for i := range totalNumTxnColumns {
 		tableWidth += txnColWidthMap[txnColumn(i)] + 2
 	}
@@ -118,11 +136,26 @@ var transactionTableConfig = tableConfig{
 		return cols
 	}(),
 	dataProvider:      txnTableDataProvider,
+	rowId:             transactionRowId,
 	defaultSortColumn: column(txnColDate),
 	defaultSortDir:    sortAsc,
+	multiSelect:       true,
+}
+
+// Transactions have no name, they are identified by where they come from and what they are,
+// so the cursor and marks stay on them when the data is reloaded
+// An entry of several accounts in a journal has a transaction for each account at the same line
+func transactionRowId(a any) string {
+	t := a.(*data.Transaction)
+	amount := t.Amount
+	if t.IsConverted() {
+		amount = t.OriginalAmount
+	}
+	return fmt.Sprintf("%s %s:%d %s %s %s %s %d %s", t.Source, t.File, t.Line, t.Date.Format(time.DateOnly),
+		t.Account, t.ToAccount, t.Type, amount, t.Description)
 }
 
-func txnTableDataProvider(transactions []*data.Transaction) tableDataSorter {
+func txnTableDataProvider(transactions []*data.Transaction, _ TableContext) tableDataSorter {
 	result := make([]any, len(transactions))
 	for i, txn := range transactions {
 		result[i] = txn
@@ -2,6 +2,7 @@ package main
 
 import (
 	"cashd/internal/model"
+	"cashd/internal/report"
 	_ "embed"
 	"fmt"
 	"log"
@@ -41,6 +42,15 @@ func main() {
 	// Send log output to the file
 	log.SetOutput(f)
 
+	if pflag.Arg(0) == "report" {
+		// Non-interactive reports
+		if err := report.Run(pflag.Args()[1:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	opts := []tea.ProgramOption{tea.WithAltScreen()}
 	if *flagDebug {
 		opts = append(opts, tea.WithoutCatchPanics())